@@ -0,0 +1,42 @@
+/*
+Copyright © 2024 Erick Kramer <erickkramer@gmail.com>
+*/
+package cmd
+
+import (
+	"ripvcs/pkg/workspace"
+	"ripvcs/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply <patch> <optional path>",
+	Short: "Apply a patch created with rv diff to all repositories",
+	Long: `Apply a patch created with rv diff to all repositories.
+
+The changes of each repository are applied to the Git repository found at the same path
+relative to the given path or to the current path. No repository is changed unless the
+patch applies to all of them.`,
+	Args: cobra.RangeArgs(1, 2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ws := newWorkspace(cmd, getRootPath(args[1:]))
+		checkFlag, _ := cmd.Flags().GetBool("check")
+
+		results, err := ws.Apply(cmd.Context(), workspace.ApplyOptions{Patch: args[0], Check: checkFlag})
+		if err != nil {
+			utils.PrintErrorMsg(err.Error())
+			exit(1)
+		}
+		if len(workspace.Failed(results)) > 0 {
+			exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+	applyCmd.Flags().IntP("workers", "w", 8, "Number of concurrent workers to use")
+	applyCmd.Flags().Bool("check", false, "Only check that the patch applies without changing any file")
+}
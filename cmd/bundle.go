@@ -0,0 +1,65 @@
+/*
+Copyright © 2024 Erick Kramer <erickkramer@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"ripvcs/pkg/workspace"
+	"ripvcs/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// bundleCmd represents the bundle command
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Manage offline workspace bundles",
+	Long: `Manage offline workspace bundles
+
+A bundle is a single archive with a git bundle of each repository of a .repos
+file and the .repos file resolved to commit hashes. It can be imported with
+rv import --from-bundle on machines without network access.`,
+}
+
+var bundleCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a bundle with the repositories of a .repos file",
+	Run: func(cmd *cobra.Command, args []string) {
+		filePath, _ := cmd.Flags().GetString("input")
+		outputPath, _ := cmd.Flags().GetString("file")
+		recursiveFlag, _ := cmd.Flags().GetBool("recursive")
+		excludeList, _ := cmd.Flags().GetStringSlice("exclude")
+
+		if len(outputPath) == 0 {
+			utils.PrintErrorMsg("Missing output file.")
+			exit(1)
+		}
+
+		ws := newWorkspace(cmd, ".")
+		startProgress(ws, "Bundling")
+		_, err := ws.CreateBundle(cmd.Context(), workspace.BundleOptions{
+			Input:     filePath,
+			Output:    outputPath,
+			Recursive: recursiveFlag,
+			Exclude:   excludeList,
+		})
+		stopProgress()
+		if err != nil {
+			utils.PrintErrorMsg(err.Error())
+			exit(1)
+		}
+		utils.PrintSeparator()
+		utils.PrintSection(fmt.Sprintf("Created bundle %s", outputPath))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bundleCmd)
+	bundleCmd.AddCommand(bundleCreateCmd)
+	bundleCreateCmd.Flags().StringP("input", "i", "", "Path to input `.repos` file")
+	bundleCreateCmd.Flags().StringP("file", "o", "", "Path to output bundle (e.g. ws.rvbundle)")
+	bundleCreateCmd.Flags().BoolP("recursive", "r", false, "Recursively bundle the .repos files found in the bundled repositories")
+	bundleCreateCmd.Flags().StringSliceP("exclude", "x", []string{}, "List of files and/or directories to exclude when performing a recursive bundle")
+	bundleCreateCmd.Flags().IntP("workers", "w", 8, "Number of concurrent workers to use")
+}
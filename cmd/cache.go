@@ -0,0 +1,141 @@
+/*
+Copyright © 2024 Erick Kramer <erickkramer@gmail.com>
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"ripvcs/utils"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of repositories used by import",
+	Long: `Manage the cache of repositories used by import
+
+The cache keeps a bare mirror of each imported repository. The mirrors are
+updated with fetch and used as reference when cloning, so only the objects
+missing from the cache are downloaded.
+
+The cache directory is given with --cache-dir or with cache-dir in the settings
+file.`,
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the cached repositories",
+	Run: func(cmd *cobra.Command, args []string) {
+		cacheDir := requireCacheDir(cmd)
+		entries, err := utils.ListCacheEntries(cmd.Context(), cacheDir)
+		if err != nil {
+			utils.PrintErrorMsg(fmt.Sprintf("Failed to list cache %s. Error: %s", cacheDir, err))
+			exit(1)
+		}
+		for _, entry := range entries {
+			utils.PrintRepoResult(utils.RepoResult{
+				Path:       entry.Path,
+				Operation:  "cache list",
+				Success:    true,
+				Output:     fmt.Sprintf("URL: %s\nSize: %.1f MiB\nLast used: %s\n", entry.URL, float64(entry.Size)/(1<<20), entry.LastUsed.Format(time.DateTime)),
+				Repository: &utils.Repository{Type: "git", URL: entry.URL},
+			})
+		}
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove cached repositories not used recently",
+	Run: func(cmd *cobra.Command, args []string) {
+		cacheDir := requireCacheDir(cmd)
+		olderThan, _ := cmd.Flags().GetDuration("older-than")
+		removeAll, _ := cmd.Flags().GetBool("all")
+
+		unusedSince := time.Now().Add(-olderThan)
+		if removeAll {
+			unusedSince = time.Now().Add(time.Hour)
+		}
+		removed, err := utils.PruneCache(cmd.Context(), cacheDir, unusedSince)
+		for _, path := range removed {
+			utils.PrintRepoResult(utils.RepoResult{Path: path, Operation: "cache prune", Success: true, Output: "Removed from cache\n"})
+		}
+		if err != nil {
+			utils.PrintErrorMsg(fmt.Sprintf("Failed to prune cache %s. Error: %s", cacheDir, err))
+			exit(1)
+		}
+	},
+}
+
+var cacheVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the integrity of the cached repositories",
+	Run: func(cmd *cobra.Command, args []string) {
+		cacheDir := requireCacheDir(cmd)
+		entries, err := utils.ListCacheEntries(cmd.Context(), cacheDir)
+		if err != nil {
+			utils.PrintErrorMsg(fmt.Sprintf("Failed to list cache %s. Error: %s", cacheDir, err))
+			exit(1)
+		}
+		paths := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			paths = append(paths, entry.Path)
+		}
+
+		numWorkers, _ := cmd.Flags().GetInt("workers")
+		order, _ := cmd.Flags().GetString("order")
+		executor := utils.Executor{Workers: numWorkers, Order: order, OnResult: utils.PrintRepoResult}
+		results := executor.Run(cmd.Context(), paths, "cache verify", func(ctx context.Context, path string) *utils.RepoResult {
+			result := &utils.RepoResult{Path: path, Operation: "cache verify"}
+			if _, err := utils.VerifyCacheMirror(ctx, path); err != nil {
+				result.Err = err
+				result.Output = "Corrupted. Remove it with 'rv cache prune --all' or delete the directory\n"
+				return result
+			}
+			result.Output = "Valid\n"
+			result.Success = true
+			return result
+		})
+		for _, result := range results {
+			if !result.Success {
+				exit(1)
+			}
+		}
+	},
+}
+
+// getCacheDir Get the cache directory given as flag or in the settings file. Empty if none
+func getCacheDir(cmd *cobra.Command) string {
+	if cacheDir, _ := cmd.Flags().GetString("cache-dir"); cacheDir != "" {
+		return cacheDir
+	}
+	settings, err := utils.LoadSettings()
+	if err != nil {
+		utils.PrintWarnMsg(fmt.Sprintf("%s\n", err))
+	}
+	return settings.CacheDir
+}
+
+// requireCacheDir Get the cache directory exiting if none is given
+func requireCacheDir(cmd *cobra.Command) string {
+	cacheDir := getCacheDir(cmd)
+	if cacheDir == "" {
+		settingsPath, _ := utils.SettingsPath()
+		utils.PrintErrorMsg(fmt.Sprintf("No cache directory given. Use --cache-dir or set cache-dir in %s", settingsPath))
+		exit(1)
+	}
+	return cacheDir
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheListCmd, cachePruneCmd, cacheVerifyCmd)
+	cacheCmd.PersistentFlags().String("cache-dir", "", "Directory of the cache. Defaults to cache-dir of the settings file")
+	cachePruneCmd.Flags().Duration("older-than", 30*24*time.Hour, "Remove the repositories not used for longer than this duration")
+	cachePruneCmd.Flags().Bool("all", false, "Remove all the cached repositories")
+	cacheVerifyCmd.Flags().IntP("workers", "w", 8, "Number of concurrent workers to use")
+}
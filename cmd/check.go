@@ -0,0 +1,93 @@
+/*
+Copyright © 2024 Erick Kramer <erickkramer@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"ripvcs/pkg/workspace"
+	"ripvcs/utils"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// checkCmd represents the check command
+var checkCmd = &cobra.Command{
+	Use:   "check <optional path>",
+	Short: "Check that the workspace matches a .repos file",
+	Long: `Check that the workspace matches a .repos file.
+
+Each entry of the .repos file is compared with the repository found at the same
+path relative to the given path or to the current path: its type, its origin URL
+and its branch, tag or commit. Each repository is reported as matching, drifted,
+missing or extra when it is not listed. Exits with a non-zero code when any
+repository is not matching.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		filePath, _ := cmd.Flags().GetString("input")
+		recursiveFlag, _ := cmd.Flags().GetBool("recursive")
+		excludeList, _ := cmd.Flags().GetStringSlice("exclude")
+		if len(filePath) == 0 {
+			utils.PrintErrorMsg("Missing input .repos file.")
+			exit(1)
+		}
+
+		ws := newWorkspace(cmd, getRootPath(args))
+		ws.Observer = nil
+		entries, err := ws.Check(cmd.Context(), workspace.ManifestOptions{
+			Input:     filePath,
+			Recursive: recursiveFlag,
+			Exclude:   excludeList,
+		})
+		if err != nil {
+			utils.PrintErrorMsg(err.Error())
+			exit(1)
+		}
+
+		if utils.IsStructuredOutput() {
+			for _, entry := range entries {
+				utils.PrintRepoResult(entry.Result())
+			}
+		} else {
+			printCheckTable(entries)
+		}
+		for _, entry := range entries {
+			if entry.State != workspace.CheckMatching {
+				exit(1)
+			}
+		}
+	},
+}
+
+// printCheckTable Print the state of each repository as a table followed by the number of repositories per state
+func printCheckTable(entries []workspace.CheckEntry) {
+	pathWidth := len("REPOSITORY")
+	for _, entry := range entries {
+		pathWidth = max(pathWidth, len(entry.Path))
+	}
+	stateColors := map[string]string{
+		workspace.CheckMatching: utils.GreenColor,
+		workspace.CheckDrifted:  utils.RedColor,
+		workspace.CheckMissing:  utils.RedColor,
+		workspace.CheckExtra:    utils.OrangeColor,
+	}
+
+	fmt.Printf("%-*s  %-8s  %s\n", pathWidth, "REPOSITORY", "STATE", "DETAILS")
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		counts[entry.State]++
+		line := fmt.Sprintf("%-*s  %s%-8s%s  %s", pathWidth, entry.Path, stateColors[entry.State], entry.State, utils.ResetColor, strings.Join(entry.Details, ", "))
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+	utils.PrintSeparator()
+	utils.PrintSection(fmt.Sprintf("%d matching, %d drifted, %d missing, %d extra",
+		counts[workspace.CheckMatching], counts[workspace.CheckDrifted], counts[workspace.CheckMissing], counts[workspace.CheckExtra]))
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+	checkCmd.Flags().IntP("workers", "w", 8, "Number of concurrent workers to use")
+	checkCmd.Flags().StringP("input", "i", "", "Path to input `.repos` file")
+	checkCmd.Flags().BoolP("recursive", "r", false, "Also check the repositories of the .repos files found in the listed repositories")
+	checkCmd.Flags().StringSliceP("exclude", "x", []string{}, "List of files and/or directories to exclude when following .repos files recursively")
+}
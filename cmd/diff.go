@@ -0,0 +1,73 @@
+/*
+Copyright © 2024 Erick Kramer <erickkramer@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"ripvcs/pkg/workspace"
+	"ripvcs/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff <optional path>",
+	Short: "Show uncommitted changes of all repositories",
+	Long: `Show uncommitted changes of all repositories.
+
+If no path is given, it shows the changes of any Git repository relative to the current path.
+The files of each diff are prefixed with the path of the repository, so the changes of the
+whole workspace can be written to a single patch file and re-applied with 'rv apply'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ws := newWorkspace(cmd, getRootPath(args))
+		repoPaths, err := ws.GitRepositories()
+		if err != nil {
+			utils.PrintErrorMsg(fmt.Sprintf("Error: %s", err))
+		}
+		reportIgnored(ws)
+		gitRepos := ws.Select(cmd.Context(), repoPaths)
+
+		stagedFlag, _ := cmd.Flags().GetBool("staged")
+		statFlag, _ := cmd.Flags().GetBool("stat")
+		nameOnlyFlag, _ := cmd.Flags().GetBool("name-only")
+		patchPath, _ := cmd.Flags().GetString("file")
+
+		results, err := ws.Diff(cmd.Context(), gitRepos, workspace.DiffOptions{
+			Staged:   stagedFlag,
+			Stat:     statFlag,
+			NameOnly: nameOnlyFlag,
+			Patch:    len(patchPath) > 0,
+		})
+		if err != nil {
+			utils.PrintErrorMsg(err.Error())
+			exit(1)
+		}
+		if len(patchPath) == 0 {
+			return
+		}
+
+		if failed := workspace.Failed(results); len(failed) > 0 {
+			utils.PrintErrorMsg(fmt.Sprintf("Failed to get the changes of %d repositories. Not writing %s", len(failed), patchPath))
+			exit(1)
+		}
+		if err := os.WriteFile(patchPath, []byte(workspace.CombinedPatch(results)), 0644); err != nil {
+			utils.PrintErrorMsg(fmt.Sprintf("Failed to write patch %s. Error: %s", patchPath, err))
+			exit(1)
+		}
+		utils.PrintSeparator()
+		utils.PrintSection(fmt.Sprintf("Wrote changes of %d repositories into %s", len(results), patchPath))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().IntP("workers", "w", 8, "Number of concurrent workers to use")
+	diffCmd.Flags().Bool("staged", false, "Show changes added to the index instead of unstaged changes")
+	diffCmd.Flags().Bool("stat", false, "Only show a summary of the changed files")
+	diffCmd.Flags().Bool("name-only", false, "Only show the names of the changed files")
+	diffCmd.Flags().StringP("file", "o", "", "Path to write a combined patch of all repositories to")
+	diffCmd.MarkFlagsMutuallyExclusive("stat", "name-only")
+}
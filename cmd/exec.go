@@ -0,0 +1,95 @@
+/*
+Copyright © 2024 Erick Kramer <erickkramer@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"ripvcs/pkg/workspace"
+	"ripvcs/utils"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// execCmd represents the exec command
+var execCmd = &cobra.Command{
+	Use:   "exec <optional path> -- <command...>",
+	Short: "Run a command in all repositories",
+	Long: `Run a command in all repositories.
+
+The command is run in any Git, Mercurial or Subversion repository found relative to the given
+path or to the current path. A command given as a single argument is run by the shell, e.g.
+rv exec -- 'make test | tail -n 1'. With --git, the arguments are passed to git instead.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		rootArgs, command := []string{}, args
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			rootArgs, command = args[:dash], args[dash:]
+		}
+		if len(rootArgs) > 1 || len(command) == 0 {
+			utils.PrintErrorMsg("Expected a single optional path followed by -- and the command to run.")
+			exit(1)
+		}
+
+		ws := newWorkspace(cmd, getRootPath(rootArgs))
+		repos := findRepositories(ws)
+
+		gitFlag, _ := cmd.Flags().GetBool("git")
+		failFastFlag, _ := cmd.Flags().GetBool("fail-fast")
+		onlyFailuresFlag, _ := cmd.Flags().GetBool("only-failures")
+		logDir, _ := cmd.Flags().GetString("log-dir")
+
+		_, summary, err := ws.Exec(cmd.Context(), repos, workspace.ExecOptions{
+			Command:      command,
+			Git:          gitFlag,
+			FailFast:     failFastFlag,
+			OnlyFailures: onlyFailuresFlag,
+			LogDir:       logDir,
+		})
+		if err != nil {
+			utils.PrintErrorMsg(err.Error())
+			exit(1)
+		}
+
+		if printExecSummary(summary) {
+			exit(1)
+		}
+	},
+}
+
+// printExecSummary Print the number of repositories of each exit code and the paths of the failed ones.
+// Returns true if any command failed
+func printExecSummary(summary workspace.ExecSummary) bool {
+	utils.PrintSeparator()
+	exitCodes := make([]int, 0, len(summary.ExitCodes))
+	for code := range summary.ExitCodes {
+		exitCodes = append(exitCodes, code)
+	}
+	slices.Sort(exitCodes)
+
+	anyFailed := false
+	for _, code := range exitCodes {
+		paths := summary.ExitCodes[code]
+		if code == 0 {
+			utils.PrintSection(fmt.Sprintf("Exit code 0: %d repositories", len(paths)))
+			continue
+		}
+		anyFailed = true
+		utils.PrintErrorMsg(fmt.Sprintf("Exit code %d: %d repositories\n  %s", code, len(paths), strings.Join(paths, "\n  ")))
+	}
+	if len(summary.Skipped) > 0 {
+		utils.PrintWarnMsg(fmt.Sprintf("Skipped after a failure: %d repositories\n", len(summary.Skipped)))
+	}
+	return anyFailed
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+	execCmd.Flags().IntP("workers", "w", 8, "Number of concurrent workers to use")
+	execCmd.Flags().Bool("git", false, "Run the arguments as a git command")
+	execCmd.Flags().Bool("fail-fast", false, "Do not start the command in more repositories once it fails")
+	execCmd.Flags().Bool("only-failures", false, "Only show the repositories where the command failed")
+	execCmd.Flags().String("log-dir", "", "Directory to write the output of each repository to")
+}
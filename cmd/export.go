@@ -4,9 +4,10 @@ Copyright © 2024 Erick Kramer <erickkramer@gmail.com>
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
+	"ripvcs/pkg/workspace"
 	"ripvcs/utils"
 
 	"github.com/spf13/cobra"
@@ -19,93 +20,84 @@ var exportCmd = &cobra.Command{
 	Short: "Export list of available repositories",
 	Long: `Export list of available repositories..
 
-If no path is given, it checks the finds any Git repository relative to the current path.`,
+If no path is given, it checks the finds any Git, Mercurial or Subversion repository relative to the current path.
+Repositories are stored under their path relative to the given path, sorted by name.
+
+With --exact-with-tags, Git repositories are exported with the tag pointing at HEAD, or with the
+commit hash when there is none. With --strict, nothing is exported when a Git repository has
+uncommitted changes, commits not pushed to origin or a version that origin does not have.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		var root string
-		if len(args) == 0 {
-			root = "."
-		} else {
-			root = utils.GetRepoPath(args[0])
-		}
-		gitRepos := utils.FindGitRepositories(root)
+		ws := newWorkspace(cmd, getRootPath(args))
+		gitRepos := findRepositories(ws)
 
-		filePath, _ := cmd.Flags().GetString("output")
+		filePath, _ := cmd.Flags().GetString("file")
 		visualizeOutput, _ := cmd.Flags().GetBool("visualize")
 
 		skipOutputFile := false
 
 		if len(filePath) == 0 {
-			if visualizeOutput {
+			if visualizeOutput || utils.IsStructuredOutput() {
 				skipOutputFile = true
 			} else {
 				utils.PrintErrorMsg("Missing output file.")
-				os.Exit(1)
+				exit(1)
 			}
 		}
 
-		numWorkers, _ := cmd.Flags().GetInt("workers")
 		getCommitsFlag, _ := cmd.Flags().GetBool("commits")
+		exactWithTagsFlag, _ := cmd.Flags().GetBool("exact-with-tags")
+		strictFlag, _ := cmd.Flags().GetBool("strict")
 
-		// Create a channel to send work to the workers with a buffer size of length gitRepos
-		jobs := make(chan string, len(gitRepos))
-		repositories := make(chan utils.RepositoryJob, len(gitRepos))
-
-		// Create a channel to indicate when the go routines have finished
-		done := make(chan bool)
-
-		var config utils.Config
-		// Initialize the repositories map
-		config.Repositories = make(map[string]utils.Repository)
-		// Iterate over the numWorkers
-		for range numWorkers {
-			go func() {
-				for repoPath := range jobs {
-					var repoPathName string
-					if repoPath == "." {
-						absPath, _ := filepath.Abs(repoPath)
-						repoPathName = filepath.Base(absPath)
-					} else {
-						repoPathName = filepath.Base(repoPath)
-					}
-					repo := utils.ParseRepositoryInfo(repoPath, getCommitsFlag)
-					repositories <- utils.RepositoryJob{RepoPath: repoPathName, Repo: repo}
-				}
-				done <- true
-			}()
+		// Only report the exported repositories when structured output is requested
+		if !utils.IsStructuredOutput() {
+			ws.Observer = nil
 		}
-		// Send each git repository path to the jobs channel
-		for _, repoPath := range gitRepos {
-			jobs <- repoPath
+		config, results := ws.Export(cmd.Context(), gitRepos, workspace.ExportOptions{
+			UseCommits:    getCommitsFlag,
+			ExactWithTags: exactWithTagsFlag,
+			Strict:        strictFlag,
+		})
+		failed := workspace.Failed(results)
+		conflict := false
+		for _, result := range failed {
+			conflict = conflict || errors.Is(result.Err, workspace.ErrExportConflict)
+			if !utils.IsStructuredOutput() {
+				utils.PrintErrorMsg(result.Err.Error())
+			}
 		}
-		close(jobs) // Close channel to signal no more work will be sent
-
-		// wait for all goroutines to finish
-		for range numWorkers {
-			<-done
+		if conflict {
+			utils.PrintErrorMsg("Repositories with conflicting names found. Nothing was exported.")
+			exit(1)
 		}
-		close(repositories)
-
-		for repoResult := range repositories {
-			config.Repositories[repoResult.RepoPath] = repoResult.Repo
+		if strictFlag && len(failed) > 0 {
+			utils.PrintErrorMsg(fmt.Sprintf("%d repositories cannot be exported in strict mode. Nothing was exported.", len(failed)))
+			exit(1)
 		}
-		yamlData, _ := yaml.Marshal(&config)
-		if visualizeOutput {
+
+		yamlData, _ := yaml.Marshal(config)
+		if visualizeOutput && !utils.IsStructuredOutput() {
 			fmt.Println(string(yamlData))
 		}
 		if !skipOutputFile {
 			err := os.WriteFile(filePath, yamlData, 0644)
 			if err != nil {
 				utils.PrintErrorMsg("Failed to export repositories to yaml file.")
-				os.Exit(1)
+				exit(1)
 			}
 		}
+		if len(failed) > 0 {
+			exit(1)
+		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(exportCmd)
 	exportCmd.Flags().IntP("workers", "w", 8, "Number of concurrent workers to use")
-	exportCmd.Flags().StringP("output", "o", "", "Path to output `.repos` file")
+	exportCmd.Flags().StringP("file", "o", "", "Path to output `.repos` file")
 	exportCmd.Flags().BoolP("commits", "c", false, "Export repositories hashes instead of branches")
+	exportCmd.Flags().Bool("exact-with-tags", false, "Export the tag pointing at HEAD, or the commit hash when there is none")
+	exportCmd.Flags().Bool("strict", false, "Fail when repositories are dirty, unpushed or their version is missing on origin")
+	exportCmd.MarkFlagsMutuallyExclusive("commits", "exact-with-tags")
 	exportCmd.Flags().BoolP("visualize", "v", false, "Show the information to be stored in the output file")
 }
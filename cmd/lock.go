@@ -0,0 +1,65 @@
+/*
+Copyright © 2024 Erick Kramer <erickkramer@gmail.com>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"ripvcs/pkg/workspace"
+	"ripvcs/utils"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// lockCmd represents the lock command
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Lock the repositories of a .repos file to commit hashes",
+	Long: `Lock the repositories of a .repos file to commit hashes
+
+Every branch, tag or empty version is resolved to the commit it points to in
+the remote repository. The result is written to a lockfile, by default the
+input file with a .lock suffix, keeping the original version as ref.
+
+When recursive, the .repos files found in the locked commits are locked too.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		filePath, _ := cmd.Flags().GetString("input")
+		outputPath, _ := cmd.Flags().GetString("file")
+		recursiveFlag, _ := cmd.Flags().GetBool("recursive")
+		excludeList, _ := cmd.Flags().GetStringSlice("exclude")
+
+		if len(outputPath) == 0 {
+			outputPath = filePath + utils.LockFileSuffix
+		}
+
+		ws := newWorkspace(cmd, ".")
+		config, _, err := ws.Lock(cmd.Context(), workspace.LockOptions{
+			Input:     filePath,
+			Recursive: recursiveFlag,
+			Exclude:   excludeList,
+		})
+		if err != nil {
+			utils.PrintErrorMsg(err.Error())
+			exit(1)
+		}
+
+		yamlData, _ := yaml.Marshal(config)
+		if err := os.WriteFile(outputPath, yamlData, 0644); err != nil {
+			utils.PrintErrorMsg(fmt.Sprintf("Failed to write lockfile %s. Error: %s", outputPath, err))
+			exit(1)
+		}
+		utils.PrintSeparator()
+		utils.PrintSection(fmt.Sprintf("Locked %d repositories into %s", len(config.Repositories), outputPath))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lockCmd)
+	lockCmd.Flags().StringP("input", "i", "", "Path to input `.repos` file")
+	lockCmd.Flags().StringP("file", "o", "", "Path to output lockfile. Defaults to the input file with a .lock suffix")
+	lockCmd.Flags().BoolP("recursive", "r", false, "Recursively lock the .repos files found in the locked repositories")
+	lockCmd.Flags().StringSliceP("exclude", "x", []string{}, "List of files and/or directories to exclude when performing a recursive lock")
+	lockCmd.Flags().IntP("workers", "w", 8, "Number of concurrent workers to use")
+}
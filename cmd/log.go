@@ -4,7 +4,7 @@ Copyright © 2024 Erick Kramer <erickkramer@gmail.com>
 package cmd
 
 import (
-	"ripvcs/utils"
+	"ripvcs/pkg/workspace"
 
 	"github.com/spf13/cobra"
 )
@@ -15,52 +15,26 @@ var logCmd = &cobra.Command{
 	Short: "Get logs of all repositories.",
 	Long: `Get logs of all repositories.
 
-If no path is given, it gets the logs of any Git repository relative to the current path.`,
+If no path is given, it gets the logs of any Git, Mercurial or Subversion repository relative to the current path.
+
+With --input, only the repositories listed in the given .repos file are used, and the listed
+repositories missing from the workspace are reported along with the found ones not listed.`,
 
 	Run: func(cmd *cobra.Command, args []string) {
-		var root string
-		if len(args) == 0 {
-			root = "."
-		} else {
-			root = utils.GetRepoPath(args[0])
-		}
-		gitRepos := utils.FindGitRepositories(root)
+		ws := newWorkspace(cmd, getRootPath(args))
+		gitRepos, manifest := manifestRepositories(cmd, ws)
 
 		onelineFlag, _ := cmd.Flags().GetBool("oneline")
-		numWorkers, _ := cmd.Flags().GetInt("workers")
 		numCommits, _ := cmd.Flags().GetInt("num-commits")
 
-		// Create a channel to send work to the workers with a buffer size of length gitRepos
-		// HINT: The buffer size specifies how many elements the channel can hold before blocking sends
-		jobs := make(chan string, len(gitRepos))
-
-		// Create a channel to indicate when the go routines have finished
-		done := make(chan bool)
-
-		// Iterate over the numWorkers
-		for range numWorkers {
-			go func() {
-				for repo := range jobs {
-					utils.PrintGitLog(repo, onelineFlag, numCommits)
-				}
-				done <- true
-			}()
-		}
-		// Send each git repository path to the jobs channel
-		for _, repo := range gitRepos {
-			jobs <- repo
-		}
-		close(jobs) // Close channel to signal no more work will be sent
-
-		// wait for all goroutines to finish
-		for range numWorkers {
-			<-done
-		}
+		ws.Log(cmd.Context(), gitRepos, workspace.LogOptions{Oneline: onelineFlag, NumCommits: numCommits})
+		reportManifest(manifest)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(logCmd)
+	addManifestFlags(logCmd)
 	logCmd.Flags().IntP("workers", "w", 8, "Number of concurrent workers to use")
 	logCmd.Flags().IntP("num-commits", "n", 4, "Show only the last n commits")
 	logCmd.Flags().BoolP("oneline", "l", false, "Show short version of logs")
@@ -0,0 +1,124 @@
+/*
+Copyright © 2024 Erick Kramer <erickkramer@gmail.com>
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"ripvcs/pkg/workspace"
+	"ripvcs/utils"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// manifestDiffCmd represents the manifest-diff command
+var manifestDiffCmd = &cobra.Command{
+	Use:   "manifest-diff <old file> <new file>",
+	Short: "Show the repositories changed between two .repos files",
+	Long: `Show the repositories changed between two .repos files.
+
+The repositories added, removed, and the ones whose type, URL or version changed
+are listed. Both .repos and .rosinstall files are supported. Use --markdown to
+get a table that can be posted as a review comment.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		oldConfig, err := utils.ParseReposFile(args[0])
+		if err != nil {
+			utils.PrintErrorMsg(fmt.Sprintf("Invalid file given {%s}. %s", args[0], err))
+			exit(1)
+		}
+		newConfig, err := utils.ParseReposFile(args[1])
+		if err != nil {
+			utils.PrintErrorMsg(fmt.Sprintf("Invalid file given {%s}. %s", args[1], err))
+			exit(1)
+		}
+		changes := workspace.DiffManifests(oldConfig, newConfig)
+		markdownFlag, _ := cmd.Flags().GetBool("markdown")
+
+		switch {
+		case utils.GetOutputFormat() == utils.JSONOutput:
+			encoded, _ := json.MarshalIndent(changes, "", "  ")
+			if changes == nil {
+				encoded = []byte("[]")
+			}
+			fmt.Println(string(encoded))
+		case utils.GetOutputFormat() == utils.NDJSONOutput:
+			for _, change := range changes {
+				encoded, _ := json.Marshal(change)
+				fmt.Println(string(encoded))
+			}
+		case markdownFlag:
+			printManifestDiffMarkdown(args[0], args[1], changes)
+		default:
+			printManifestDiff(changes)
+		}
+	},
+}
+
+// describeRepository Get the URL and version of a repository in a single line
+func describeRepository(repo *utils.Repository) string {
+	if repo.Version == "" {
+		return repo.URL
+	}
+	return fmt.Sprintf("%s (%s)", repo.URL, repo.Version)
+}
+
+// manifestDiffSummary Count the changes of each kind
+func manifestDiffSummary(changes []workspace.ManifestChange) string {
+	counts := make(map[string]int)
+	for _, change := range changes {
+		counts[change.Change]++
+	}
+	return fmt.Sprintf("%d added, %d removed, %d changed",
+		counts[workspace.ManifestAdded], counts[workspace.ManifestRemoved], counts[workspace.ManifestChanged])
+}
+
+// printManifestDiff Print one line per change followed by the details of the changed repositories
+func printManifestDiff(changes []workspace.ManifestChange) {
+	for _, change := range changes {
+		switch change.Change {
+		case workspace.ManifestAdded:
+			fmt.Printf("%s+ %s: %s%s\n", utils.GreenColor, change.Name, describeRepository(change.New), utils.ResetColor)
+		case workspace.ManifestRemoved:
+			fmt.Printf("%s- %s: %s%s\n", utils.RedColor, change.Name, describeRepository(change.Old), utils.ResetColor)
+		default:
+			fmt.Printf("%s~ %s%s\n", utils.OrangeColor, change.Name, utils.ResetColor)
+			for _, detail := range change.Details {
+				fmt.Printf("    %s\n", detail)
+			}
+		}
+	}
+	utils.PrintSeparator()
+	utils.PrintSection(manifestDiffSummary(changes))
+}
+
+// printManifestDiffMarkdown Print the changes as a markdown table
+func printManifestDiffMarkdown(oldPath string, newPath string, changes []workspace.ManifestChange) {
+	fmt.Printf("### Repository changes: `%s` → `%s`\n\n", oldPath, newPath)
+	if len(changes) == 0 {
+		fmt.Println("No repository changes.")
+		return
+	}
+	fmt.Println("| Repository | Change | Details |")
+	fmt.Println("| --- | --- | --- |")
+	for _, change := range changes {
+		var details string
+		switch change.Change {
+		case workspace.ManifestAdded:
+			details = describeRepository(change.New)
+		case workspace.ManifestRemoved:
+			details = describeRepository(change.Old)
+		default:
+			details = strings.Join(change.Details, "<br>")
+		}
+		fmt.Printf("| `%s` | %s | %s |\n", change.Name, change.Change, strings.ReplaceAll(details, "|", "\\|"))
+	}
+	fmt.Printf("\n%s\n", manifestDiffSummary(changes))
+}
+
+func init() {
+	rootCmd.AddCommand(manifestDiffCmd)
+	manifestDiffCmd.Flags().Bool("markdown", false, "Print the changes as a markdown table")
+}
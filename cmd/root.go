@@ -5,9 +5,21 @@ Copyright © 2024 Erick Kramer <erickkramer@gmail.com>
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"os/signal"
+	"path"
+	"regexp"
+	"ripvcs/pkg/workspace"
+	"ripvcs/utils"
+	"strings"
+	"sync/atomic"
+	"syscall"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -15,13 +27,336 @@ var rootCmd = &cobra.Command{
 	Use:   "rv",
 	Short: "Fast CLI tool for managing multiple Git repositories.",
 	Long:  ``,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		outputFormat, _ := cmd.Flags().GetString("output")
+		if err := utils.SetOutputFormat(outputFormat); err != nil {
+			return err
+		}
+		order, _ := cmd.Flags().GetString("order")
+		if err := utils.ValidateOrder(order); err != nil {
+			return err
+		}
+		filter, err := parseFilter(cmd.Root().PersistentFlags())
+		if err != nil {
+			return err
+		}
+		repoFilter = filter
+		showIgnored, _ = cmd.Flags().GetBool("show-ignored")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		runContext = cmd.Context()
+		if timeout > 0 {
+			runContext, cancelRun = context.WithTimeout(runContext, timeout)
+		}
+		cmd.SetContext(runContext)
+		return nil
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if runInterrupted() {
+			exit(1)
+		}
+		utils.FlushRepoResults()
+		if cancelRun != nil {
+			cancelRun()
+		}
+	},
 }
 
+var (
+	// runContext Context of the running command. Done on SIGINT, SIGTERM or once the timeout expires
+	runContext context.Context = context.Background()
+	cancelRun  context.CancelFunc
+	// Number of repositories processed by the running command
+	numFinished    atomic.Int32
+	numFailed      atomic.Int32
+	numInterrupted atomic.Int32
+	// progress Live progress view. Only set while an interactive command is running
+	progress *utils.ProgressRenderer
+	// repoFilter Selection of repositories given through the global flags
+	repoFilter *workspace.Filter
+	// showIgnored List the repositories left out by the ignore patterns and .rvignore files
+	showIgnored bool
+)
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err := rootCmd.ExecuteContext(ctx)
 	if err != nil {
 		os.Exit(1)
 	}
 }
+
+// exit Print any buffered results before exiting with the given code
+func exit(code int) {
+	stopProgress()
+	utils.FlushRepoResults()
+	if runInterrupted() {
+		printInterruptedSummary()
+		if errors.Is(runContext.Err(), context.Canceled) {
+			code = 130
+		}
+	}
+	if cancelRun != nil {
+		cancelRun()
+	}
+	os.Exit(code)
+}
+
+// runInterrupted Check if the running command was interrupted or timed out
+func runInterrupted() bool {
+	return runContext.Err() != nil
+}
+
+// printInterruptedSummary Print what was processed before the command was interrupted
+func printInterruptedSummary() {
+	reason := "Interrupted"
+	if errors.Is(runContext.Err(), context.DeadlineExceeded) {
+		reason = "Timed out"
+	}
+	utils.PrintSeparator()
+	utils.PrintWarnMsg(fmt.Sprintf("%s: %d repositories finished (%d failed), %d not finished\n",
+		reason, numFinished.Load(), numFailed.Load(), numInterrupted.Load()))
+}
+
+// newWorkspace Create a workspace that prints the results as soon as they are available
+func newWorkspace(cmd *cobra.Command, root string) *workspace.Workspace {
+	ws := workspace.New(root)
+	if numWorkers, err := cmd.Flags().GetInt("workers"); err == nil {
+		ws.Workers = numWorkers
+	}
+	ws.RepoTimeout, _ = cmd.Flags().GetDuration("repo-timeout")
+	ws.Order, _ = cmd.Flags().GetString("order")
+	ws.Observer = workspace.ObserverFunc(printEvent)
+	ws.Filter = repoFilter
+	ws.Discovery.MaxDepth, _ = cmd.Flags().GetInt("max-depth")
+	ws.Discovery.FollowSymlinks, _ = cmd.Flags().GetBool("follow-symlinks")
+	ws.Discovery.Ignore, _ = cmd.Flags().GetStringSlice("ignore")
+	ws.Discovery.IncludeSubmodules, _ = cmd.Flags().GetBool("include-submodules")
+	return ws
+}
+
+// startProgress Show a live progress view when printing text to an interactive terminal
+func startProgress(ws *workspace.Workspace, operation string) {
+	if utils.IsStructuredOutput() || !utils.IsTerminal(os.Stdout) {
+		return
+	}
+	ws.ReportProgress = true
+	progress = utils.NewProgressRenderer(operation)
+	progress.Start()
+}
+
+// stopProgress Remove the live progress view, if any
+func stopProgress() {
+	if progress != nil {
+		progress.Stop()
+		progress = nil
+	}
+}
+
+// printOutput Run a function printing to stdout without breaking the progress view
+func printOutput(print func()) {
+	if progress != nil {
+		progress.Suspend(print)
+		return
+	}
+	print()
+}
+
+// printEvent Print the progress of a workspace operation
+func printEvent(event workspace.Event) {
+	switch event.Kind {
+	case workspace.ReposQueued:
+		if progress != nil {
+			progress.AddTotal(event.Total)
+		}
+	case workspace.RepoStarted:
+		if progress != nil {
+			progress.RepoStarted(event.Path)
+		}
+	case workspace.RepoProgress:
+		if progress != nil {
+			progress.Update(event.Path, *event.Progress)
+		}
+	case workspace.RepoFinished:
+		if runInterrupted() && workspace.Interrupted(*event.Result) {
+			numInterrupted.Add(1)
+		} else {
+			numFinished.Add(1)
+			if !event.Result.Success {
+				numFailed.Add(1)
+			}
+		}
+		if progress != nil {
+			progress.RepoFinished(event.Path)
+		}
+		printOutput(func() {
+			utils.PrintRepoResult(*event.Result)
+		})
+	case workspace.ManifestStarted:
+		printOutput(func() {
+			utils.PrintSeparator()
+			utils.PrintSection(fmt.Sprintf("Importing from %s", event.Path))
+			utils.PrintSeparator()
+		})
+	case workspace.ManifestExcluded:
+		action := "cloning"
+		switch event.Operation {
+		case "lock":
+			action = "locking"
+		case "manifest":
+			action = "following"
+		}
+		printOutput(func() {
+			utils.PrintSeparator()
+			utils.PrintWarnMsg(fmt.Sprintf("Excluded %s from '%s'\n", action, event.Path))
+		})
+	}
+}
+
+// getRootPath Get the root path given as argument or the current path
+func getRootPath(args []string) string {
+	if len(args) == 0 {
+		return "."
+	}
+	return utils.GetRepoPath(args[0])
+}
+
+// findRepositories Get the repositories found relative to the given root matching the global filters
+func findRepositories(ws *workspace.Workspace) []string {
+	discovery, err := ws.Discover()
+	if err != nil {
+		utils.PrintErrorMsg(fmt.Sprintf("Error: %s", err))
+	}
+	if len(discovery.Bare) > 0 {
+		utils.PrintWarnMsg(fmt.Sprintf("Skipped %d bare repositories:\n  %s\n", len(discovery.Bare), strings.Join(discovery.Bare, "\n  ")))
+		utils.PrintSeparator()
+	}
+	reportIgnored(ws)
+	return ws.Select(runContext, discovery.Repositories)
+}
+
+// reportIgnored Print the repositories left out by the ignore patterns when requested
+func reportIgnored(ws *workspace.Workspace) {
+	if !showIgnored {
+		return
+	}
+	ignored, err := ws.IgnoredRepositories()
+	if err != nil {
+		utils.PrintErrorMsg(fmt.Sprintf("Error: %s", err))
+	}
+	if len(ignored) > 0 {
+		utils.PrintWarnMsg(fmt.Sprintf("Ignored %d repositories:\n  %s\n", len(ignored), strings.Join(ignored, "\n  ")))
+		utils.PrintSeparator()
+	}
+}
+
+// addManifestFlags Add the flags selecting the repositories listed in a .repos file
+func addManifestFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("input", "i", "", "Only use the repositories listed in this `.repos` file, reporting missing and unlisted ones")
+	cmd.Flags().BoolP("recursive", "r", false, "Also use the repositories of the .repos files found in the listed repositories")
+	cmd.Flags().StringSliceP("exclude", "x", []string{}, "List of files and/or directories to exclude when following .repos files recursively")
+}
+
+// manifestRepositories Get the repositories listed in the .repos file given as input, or the
+// ones found relative to the given root when there is none
+func manifestRepositories(cmd *cobra.Command, ws *workspace.Workspace) ([]string, *workspace.Manifest) {
+	filePath, _ := cmd.Flags().GetString("input")
+	if len(filePath) == 0 {
+		return findRepositories(ws), nil
+	}
+	recursiveFlag, _ := cmd.Flags().GetBool("recursive")
+	excludeList, _ := cmd.Flags().GetStringSlice("exclude")
+	manifest, err := ws.ResolveManifest(cmd.Context(), workspace.ManifestOptions{
+		Input:     filePath,
+		Recursive: recursiveFlag,
+		Exclude:   excludeList,
+	})
+	if err != nil {
+		utils.PrintErrorMsg(err.Error())
+		exit(1)
+	}
+	return manifest.Paths, manifest
+}
+
+// reportManifest Print the repositories missing from the workspace and the ones not listed in the .repos file
+func reportManifest(manifest *workspace.Manifest) {
+	if manifest == nil || (len(manifest.Missing) == 0 && len(manifest.Stray) == 0) {
+		return
+	}
+	utils.PrintSeparator()
+	if len(manifest.Missing) > 0 {
+		utils.PrintWarnMsg(fmt.Sprintf("Missing %d listed repositories:\n  %s\n", len(manifest.Missing), strings.Join(manifest.Missing, "\n  ")))
+	}
+	if len(manifest.Stray) > 0 {
+		utils.PrintWarnMsg(fmt.Sprintf("Found %d repositories not listed:\n  %s\n", len(manifest.Stray), strings.Join(manifest.Stray, "\n  ")))
+	}
+}
+
+// parseFilter Get the repository selection given through the global flags.
+// Flags are read from the root command since a command can shadow them with its own flags
+func parseFilter(flags *pflag.FlagSet) (*workspace.Filter, error) {
+	filter := &workspace.Filter{}
+	filter.Only, _ = flags.GetStringSlice("only")
+	filter.Skip, _ = flags.GetStringSlice("skip")
+	filter.Branch, _ = flags.GetString("branch")
+	filter.ChangedSince, _ = flags.GetString("changed-since")
+	filter.Groups, _ = flags.GetStringSlice("group")
+	filter.SkipGroups, _ = flags.GetStringSlice("skip-group")
+	for _, glob := range append(append([]string{}, filter.Only...), filter.Skip...) {
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob '%s'. Error: %w", glob, err)
+		}
+	}
+	if urlMatch, _ := flags.GetString("url-match"); urlMatch != "" {
+		expression, err := regexp.Compile(urlMatch)
+		if err != nil {
+			return nil, fmt.Errorf("invalid url-match expression '%s'. Error: %w", urlMatch, err)
+		}
+		filter.URLMatch = expression
+	}
+	dirtyChanged, cleanChanged := flags.Changed("dirty"), flags.Changed("clean")
+	dirty, _ := flags.GetBool("dirty")
+	clean, _ := flags.GetBool("clean")
+	switch {
+	case dirtyChanged && cleanChanged && dirty == clean:
+		return nil, fmt.Errorf("--dirty=%t and --clean=%t select opposite repositories", dirty, clean)
+	case dirtyChanged:
+		filter.Dirty = &dirty
+	case cleanChanged:
+		notClean := !clean
+		filter.Dirty = &notClean
+	}
+	return filter, nil
+}
+
+// normalizeFlagName Accept --format as an alias of the global --output flag
+func normalizeFlagName(f *pflag.FlagSet, name string) pflag.NormalizedName {
+	if name == "format" {
+		name = "output"
+	}
+	return pflag.NormalizedName(name)
+}
+
+func init() {
+	rootCmd.PersistentFlags().String("output", utils.TextOutput, "Output format of the results (text, json, ndjson). Also accepted as --format")
+	rootCmd.SetGlobalNormalizationFunc(normalizeFlagName)
+	rootCmd.PersistentFlags().String("order", utils.OrderPath, "Order of the results (path, completion)")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "Maximum duration of the whole command (e.g. 10m). 0 means no limit")
+	rootCmd.PersistentFlags().Duration("repo-timeout", 0, "Maximum duration of the operation on each repository (e.g. 2m). 0 means no limit")
+	rootCmd.PersistentFlags().StringSlice("only", []string{}, "Only select repositories whose path or name matches any of these globs")
+	rootCmd.PersistentFlags().StringSlice("skip", []string{}, "Leave out repositories whose path or name matches any of these globs")
+	rootCmd.PersistentFlags().String("url-match", "", "Only select repositories whose URL matches this regular expression")
+	rootCmd.PersistentFlags().String("branch", "", "Only select repositories on this branch, or .repos entries with this version")
+	rootCmd.PersistentFlags().Bool("dirty", false, "Only select repositories with uncommitted changes (--dirty=false selects clean ones)")
+	rootCmd.PersistentFlags().Bool("clean", false, "Only select repositories without uncommitted changes")
+	rootCmd.PersistentFlags().StringSlice("group", []string{}, "Only select repositories tagged with any of these groups")
+	rootCmd.PersistentFlags().StringSlice("skip-group", []string{}, "Leave out repositories tagged with any of these groups")
+	rootCmd.PersistentFlags().String("changed-since", "", "Only select git repositories whose working tree differs from this commit, branch or tag")
+	rootCmd.PersistentFlags().Int("max-depth", 0, "Maximum number of directories below the given path searched for repositories. 0 means no limit")
+	rootCmd.PersistentFlags().Bool("follow-symlinks", false, "Also search for repositories in the directories symbolic links point to")
+	rootCmd.PersistentFlags().StringSlice("ignore", []string{}, "Leave out the paths matching these patterns in .rvignore syntax when searching for repositories")
+	rootCmd.PersistentFlags().Bool("show-ignored", false, "List the repositories left out by --ignore and .rvignore files")
+	rootCmd.PersistentFlags().Bool("include-submodules", false, "Also use the checkouts of the submodules of the repositories found as repositories")
+}
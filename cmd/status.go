@@ -4,7 +4,7 @@ Copyright © 2024 Erick Kramer <erickkramer@gmail.com>
 package cmd
 
 import (
-	"ripvcs/utils"
+	"ripvcs/pkg/workspace"
 
 	"github.com/spf13/cobra"
 )
@@ -15,51 +15,28 @@ var statusCmd = &cobra.Command{
 	Short: "Check status of all repositories",
 	Long: `Check status of all repositories.
 
-If no path is given, it checks the status of any Git repository relative to the current path.`,
+If no path is given, it checks the status of any Git, Mercurial or Subversion repository relative to the current path.
+
+With --input, only the repositories listed in the given .repos file are used, and the listed
+repositories missing from the workspace are reported along with the found ones not listed.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		var root string
-		if len(args) == 0 {
-			root = "."
-		} else {
-			root = utils.GetRepoPath(args[0])
-		}
-		gitRepos := utils.FindGitRepositories(root)
+		ws := newWorkspace(cmd, getRootPath(args))
+		gitRepos, manifest := manifestRepositories(cmd, ws)
 
 		plainStatus, _ := cmd.Flags().GetBool("plain")
 		skipEmtpy, _ := cmd.Flags().GetBool("skip-empty")
-		numWorkers, _ := cmd.Flags().GetInt("workers")
-
-		// Create a channel to send work to the workers with a buffer size of length gitRepos
-		jobs := make(chan string, len(gitRepos))
-
-		// Create a channel to indicate when the go routines have finished
-		done := make(chan bool)
-
-		// Iterate over the numWorkers
-		for range numWorkers {
-			go func() {
-				for repo := range jobs {
-					utils.PrintGitStatus(repo, skipEmtpy, plainStatus)
-				}
-				done <- true
-			}()
-		}
-		// Send each git repository path to the jobs channel
-		for _, repo := range gitRepos {
-			jobs <- repo
-		}
-		close(jobs) // Close channel to signal no more work will be sent
+		trackingFlag, _ := cmd.Flags().GetBool("tracking")
 
-		// wait for all goroutines to finish
-		for range numWorkers {
-			<-done
-		}
+		ws.Status(cmd.Context(), gitRepos, workspace.StatusOptions{Plain: plainStatus, SkipEmpty: skipEmtpy, Tracking: trackingFlag})
+		reportManifest(manifest)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(statusCmd)
+	addManifestFlags(statusCmd)
 	statusCmd.Flags().IntP("workers", "w", 8, "Number of concurrent workers to use")
 	statusCmd.Flags().BoolP("plain", "p", false, "Show simpler status report")
 	statusCmd.Flags().BoolP("skip-empty", "s", false, "Skip repositories with clean working tree.")
+	statusCmd.Flags().BoolP("tracking", "t", false, "Show the remote branch tracked by the current branch of Git repositories")
 }
@@ -0,0 +1,44 @@
+/*
+Copyright © 2024 Erick Kramer <erickkramer@gmail.com>
+*/
+package cmd
+
+import (
+	"ripvcs/pkg/workspace"
+
+	"github.com/spf13/cobra"
+)
+
+// submoduleCmd represents the submodule command
+var submoduleCmd = &cobra.Command{
+	Use:   "submodule",
+	Short: "Manage the submodules of all repositories",
+}
+
+var submoduleUpdateCmd = &cobra.Command{
+	Use:   "update <optional path>",
+	Short: "Initialize and update the submodules of all repositories",
+	Long: `Initialize and update the submodules of all repositories.
+
+Runs git submodule update --init in every Git repository with submodules found relative to
+the given path or to the current path, checking out the commits each repository records.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ws := newWorkspace(cmd, getRootPath(args))
+		gitRepos := findRepositories(ws)
+		recursiveFlag, _ := cmd.Flags().GetBool("recursive")
+
+		startProgress(ws, "Updating submodules")
+		results := ws.SubmoduleUpdate(cmd.Context(), gitRepos, workspace.SubmoduleOptions{Recursive: recursiveFlag})
+		stopProgress()
+		if len(workspace.Failed(results)) > 0 {
+			exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(submoduleCmd)
+	submoduleCmd.AddCommand(submoduleUpdateCmd)
+	submoduleUpdateCmd.Flags().IntP("workers", "w", 8, "Number of concurrent workers to use")
+	submoduleUpdateCmd.Flags().BoolP("recursive", "r", false, "Also update the submodules of the submodules")
+}
@@ -4,8 +4,7 @@ Copyright © 2024 Erick Kramer <erickkramer@gmail.com>
 package cmd
 
 import (
-	"fmt"
-	"os"
+	"ripvcs/pkg/workspace"
 	"ripvcs/utils"
 
 	"github.com/spf13/cobra"
@@ -21,19 +20,23 @@ It allows to easily run Git switch operation on the given repository.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// var repoName string
 		if len(args) == 0 {
-			utils.PrintErrorMsg("Repository Name or Path not given\n")
-			os.Exit(1)
+			utils.PrintErrorMsg("Repository Name or Path not given")
+			exit(1)
 		}
 		repoPath := utils.GetRepoPath(args[0])
 
 		if !utils.IsGitRepository(repoPath) {
-			fmt.Println("Directory given is not a git repository")
-			os.Exit(1)
+			utils.PrintErrorMsg("Directory given is not a git repository")
+			exit(1)
 		}
 		createBranch, _ := cmd.Flags().GetBool("create")
 		detachHead, _ := cmd.Flags().GetBool("detach")
 		branch, _ := cmd.Flags().GetString("branch")
-		utils.PrintGitSwitch(repoPath, branch, createBranch, detachHead)
+		result := workspace.Switch(cmd.Context(), repoPath, workspace.SwitchOptions{Version: branch, Create: createBranch, Detach: detachHead})
+		utils.PrintRepoResult(result)
+		if !result.Success {
+			exit(1)
+		}
 	},
 }
 
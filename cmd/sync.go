@@ -4,7 +4,7 @@ Copyright © 2024 Erick Kramer <erickkramer@gmail.com>
 package cmd
 
 import (
-	"ripvcs/utils"
+	"ripvcs/pkg/workspace"
 
 	"github.com/spf13/cobra"
 )
@@ -16,48 +16,29 @@ var syncCmd = &cobra.Command{
 	Long: `Synchronize all found repositories.
 
 It stashes all changes found in the repostory, pull latest remote,
-and bring back staged changes.`,
+and bring back staged changes.
+
+With --recurse-submodules, the submodules of Git repositories are updated too. Submodule
+checkouts found inside their parent repository are then left to it.
+
+With --input, only the repositories listed in the given .repos file are used, and the listed
+repositories missing from the workspace are reported along with the found ones not listed.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		var root string
-		if len(args) == 0 {
-			root = "."
-		} else {
-			root = utils.GetRepoPath(args[0])
-		}
-		gitRepos := utils.FindGitRepositories(root)
-
-		numWorkers, _ := cmd.Flags().GetInt("workers")
-
-		// Create a channel to send work to the workers with a buffer size of length gitRepos
-		// HINT: The buffer size specifies how many elements the channel can hold before blocking sends
-		jobs := make(chan string, len(gitRepos))
-
-		// Create a channel to indicate when the go routines have finished
-		done := make(chan bool)
-
-		// Iterate over the numWorkers
-		for range numWorkers {
-			go func() {
-				for repo := range jobs {
-					utils.PrintGitSync(repo)
-				}
-				done <- true
-			}()
-		}
-		// Send each git repository path to the jobs channel
-		for _, repo := range gitRepos {
-			jobs <- repo
-		}
-		close(jobs) // Close channel to signal no more work will be sent
-
-		// wait for all goroutines to finish
-		for range numWorkers {
-			<-done
-		}
+		ws := newWorkspace(cmd, getRootPath(args))
+		gitRepos, manifest := manifestRepositories(cmd, ws)
+
+		recurseSubmodules, _ := cmd.Flags().GetBool("recurse-submodules")
+
+		startProgress(ws, "Syncing")
+		ws.Sync(cmd.Context(), gitRepos, workspace.PullOptions{RecurseSubmodules: recurseSubmodules})
+		stopProgress()
+		reportManifest(manifest)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(syncCmd)
+	addManifestFlags(syncCmd)
 	syncCmd.Flags().IntP("workers", "w", 8, "Number of concurrent workers to use")
+	syncCmd.Flags().BoolP("recurse-submodules", "s", false, "Also update the submodules of Git repositories to the commits they record")
 }
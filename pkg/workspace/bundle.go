@@ -0,0 +1,177 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"ripvcs/utils"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Layout of a workspace bundle
+const (
+	bundleManifest = "manifest.repos"
+	bundlesDir     = "bundles"
+)
+
+// BundleOptions Settings of a bundle operation
+type BundleOptions struct {
+	// Input Path to the .repos file to bundle
+	Input string
+	// Output Path to the bundle to write
+	Output string
+	// Recursive Also bundle the repositories of the .repos files found in the bundled repositories
+	Recursive bool
+	// Exclude Files and/or directories to exclude when performing a recursive bundle
+	Exclude []string
+	// EnablePrompt Allow git to prompt for credentials
+	EnablePrompt bool
+}
+
+// CreateBundle Write an archive with a git bundle of each repository of a .repos file,
+// locked to the commit its version points to, and the resolved .repos file.
+// The workspace can then be imported without network access using ImportOptions.FromBundle
+func (w *Workspace) CreateBundle(ctx context.Context, opts BundleOptions) ([]Result, error) {
+	locked, results, err := w.Lock(ctx, LockOptions{
+		Input:        opts.Input,
+		Recursive:    opts.Recursive,
+		Exclude:      opts.Exclude,
+		EnablePrompt: opts.EnablePrompt,
+	})
+	if err != nil {
+		return results, err
+	}
+
+	tempDir, err := os.MkdirTemp("", "rv-bundle-")
+	if err != nil {
+		return results, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	bundleResults := w.forEach(ctx, sortedKeys(locked.Repositories), "bundle", func(ctx context.Context, dirName string) *Result {
+		repo := locked.Repositories[dirName]
+		result := &Result{Path: dirName, Operation: "bundle", Repository: &repo}
+		if repo.Type != "git" {
+			result.Err = fmt.Errorf("unsupported repository type %s, only git repositories can be bundled", repo.Type)
+			return result
+		}
+		bundlePath := filepath.Join(tempDir, bundlesDir, dirName+".bundle")
+		if err := os.MkdirAll(filepath.Dir(bundlePath), 0755); err != nil {
+			result.Err = err
+			return result
+		}
+		if err := utils.CreateGitBundle(ctx, repo.URL, repo.Version, bundlePath, opts.EnablePrompt); err != nil {
+			result.Err = err
+			return result
+		}
+		result.Output = fmt.Sprintf("Bundled git repository '%s' with version '%s'\n", repo.URL, repo.Version)
+		result.Success = true
+		return result
+	})
+	results = append(results, bundleResults...)
+	if err := ctx.Err(); err != nil {
+		return results, fmt.Errorf("interrupted while bundling %s: %w", opts.Input, err)
+	}
+	if len(Failed(bundleResults)) > 0 {
+		return results, fmt.Errorf("failed while bundling %s", opts.Input)
+	}
+
+	manifest, err := yaml.Marshal(locked)
+	if err != nil {
+		return results, err
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, bundleManifest), manifest, 0644); err != nil {
+		return results, err
+	}
+	if err := utils.WriteTarGz(opts.Output, tempDir); err != nil {
+		return results, fmt.Errorf("failed to write bundle %s. Error: %w", opts.Output, err)
+	}
+	return results, nil
+}
+
+// importBundle Check out the repositories of a bundle created by CreateBundle
+func (w *Workspace) importBundle(ctx context.Context, opts ImportOptions) ([]Result, error) {
+	tempDir, err := os.MkdirTemp("", "rv-bundle-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := utils.ExtractTarGz(opts.FromBundle, tempDir); err != nil {
+		return nil, fmt.Errorf("invalid bundle given {%s}. %s", opts.FromBundle, err)
+	}
+	config, err := utils.ParseReposFile(filepath.Join(tempDir, bundleManifest))
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle given {%s}. %s", opts.FromBundle, err)
+	}
+	w.notify(Event{Kind: ManifestStarted, Path: opts.FromBundle, Operation: "import"})
+	config.Repositories = w.selectEntries(ctx, config.Repositories)
+
+	paths := make([]string, 0, len(config.Repositories))
+	dirNames := make(map[string]string, len(config.Repositories))
+	for dirName := range config.Repositories {
+		repoPath := filepath.Join(w.Root, dirName)
+		paths = append(paths, repoPath)
+		dirNames[repoPath] = dirName
+	}
+
+	results := w.forEach(ctx, paths, "import", func(ctx context.Context, repoPath string) *Result {
+		repo := config.Repositories[dirNames[repoPath]]
+		bundlePath := filepath.Join(tempDir, bundlesDir, dirNames[repoPath]+".bundle")
+		return cloneBundle(ctx, repoPath, bundlePath, repo, opts)
+	})
+
+	if err := ctx.Err(); err != nil {
+		return results, fmt.Errorf("interrupted while importing %s: %w", opts.FromBundle, err)
+	}
+	if len(Failed(results)) > 0 {
+		return results, fmt.Errorf("failed while importing %s", opts.FromBundle)
+	}
+	return results, nil
+}
+
+// cloneBundle Check out a single repository of a bundle
+func cloneBundle(ctx context.Context, repoPath string, bundlePath string, repo utils.Repository, opts ImportOptions) *Result {
+	result := &Result{Path: repoPath, Operation: "import", Repository: &repo}
+
+	_, statErr := os.Stat(repoPath)
+	existing := statErr == nil
+	if existing && opts.OverwriteExisting {
+		if err := os.RemoveAll(repoPath); err != nil {
+			result.Err = fmt.Errorf("failed to remove existing cloning path %s. Error: %w", repoPath, err)
+			return result
+		}
+		existing = false
+	}
+	if existing {
+		if sha, err := utils.GitCommitSha(ctx, repoPath); err == nil && sha == repo.Version {
+			result.Output = fmt.Sprintf("Skipped cloning existing git repository '%s'\n", repo.URL)
+			result.Success = true
+			result.Skipped = true
+			recordTags(ctx, repoPath, repo, result)
+			addRemotes(ctx, repoPath, repo, false, false, result)
+			return result
+		}
+	}
+
+	if err := utils.CloneGitBundle(ctx, bundlePath, repo.URL, repo.Version, repoPath); err != nil {
+		if !existing {
+			os.RemoveAll(repoPath)
+		}
+		result.Output = fmt.Sprintf("Failed to clone git repository '%s' with version '%s'\n", repo.URL, repo.Version)
+		result.Err = err
+		return result
+	}
+	if existing {
+		result.Output = fmt.Sprintf("Successfully switched to version '%s' in existing git repository '%s'\n", repo.Version, repo.URL)
+	} else {
+		result.Output = fmt.Sprintf("Successfully cloned git repository '%s' with version '%s'\n", repo.URL, repo.Version)
+	}
+	result.Success = true
+	recordTags(ctx, repoPath, repo, result)
+	// Bundles are imported offline, so the remotes are added without fetching them
+	addRemotes(ctx, repoPath, repo, false, false, result)
+	return result
+}
@@ -0,0 +1,182 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"ripvcs/utils"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// States of a repository compared to its entry in a .repos file
+const (
+	// CheckMatching The repository matches its entry
+	CheckMatching = "matching"
+	// CheckDrifted The type, URL or version of the repository differs from its entry
+	CheckDrifted = "drifted"
+	// CheckMissing The entry has no repository in the workspace
+	CheckMissing = "missing"
+	// CheckExtra The repository is not listed in the .repos file
+	CheckExtra = "extra"
+)
+
+// CheckEntry State of a repository of the workspace compared to a .repos file
+type CheckEntry struct {
+	Path  string
+	State string
+	// Details Differences of a drifted repository, or the reason it could not be compared
+	Details []string
+	// Expected Entry of the repository in the .repos file. Nil for extra repositories
+	Expected *utils.Repository
+}
+
+// Check Compare the repositories of the workspace with the ones listed in a .repos file.
+// Entries are sorted by path
+func (w *Workspace) Check(ctx context.Context, opts ManifestOptions) ([]CheckEntry, error) {
+	manifest, err := w.ResolveManifest(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]CheckEntry)
+	names := make(map[string]string, len(manifest.Repositories))
+	paths := make([]string, 0, len(manifest.Repositories))
+	for dirName := range manifest.Repositories {
+		repoPath := filepath.Join(w.Root, dirName)
+		names[repoPath] = dirName
+		paths = append(paths, repoPath)
+	}
+	var mutex sync.Mutex
+	w.forEach(ctx, paths, "check", func(ctx context.Context, repoPath string) *Result {
+		repo := manifest.Repositories[names[repoPath]]
+		entry := checkRepository(ctx, repoPath, repo)
+		entry.Expected = &repo
+		mutex.Lock()
+		entries[repoPath] = entry
+		mutex.Unlock()
+		result := entry.Result()
+		return &result
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("interrupted while checking %s: %w", opts.Input, err)
+	}
+
+	for _, repoPath := range manifest.Stray {
+		entries[repoPath] = CheckEntry{Path: repoPath, State: CheckExtra}
+	}
+	checked := make([]CheckEntry, 0, len(entries))
+	for _, repoPath := range sortedKeys(entries) {
+		checked = append(checked, entries[repoPath])
+	}
+	return checked, nil
+}
+
+// Result Convert a checked repository into a result, successful only when it matches
+func (entry CheckEntry) Result() Result {
+	output := entry.State + "\n"
+	if len(entry.Details) > 0 {
+		output = fmt.Sprintf("%s: %s\n", entry.State, strings.Join(entry.Details, ", "))
+	}
+	return Result{Path: entry.Path, Operation: "check", Success: entry.State == CheckMatching, Output: output, Repository: entry.Expected}
+}
+
+// checkRepository Compare a repository with its entry in a .repos file
+func checkRepository(ctx context.Context, repoPath string, repo utils.Repository) CheckEntry {
+	entry := CheckEntry{Path: repoPath, State: CheckMatching}
+	drifted := func(details ...string) CheckEntry {
+		entry.State = CheckDrifted
+		entry.Details = append(entry.Details, details...)
+		return entry
+	}
+
+	if isArchive(repo) {
+		if _, err := os.Stat(repoPath); err != nil {
+			entry.State = CheckMissing
+			return entry
+		}
+		url, modified, err := utils.ArchiveInfo(repoPath)
+		if err != nil {
+			return drifted(err.Error())
+		}
+		if url != repo.URL {
+			drifted(fmt.Sprintf("url '%s' != '%s'", url, repo.URL))
+		}
+		if len(modified) > 0 {
+			drifted(modified...)
+		}
+		return entry
+	}
+
+	vcs, err := utils.DetectVCS(repoPath)
+	if err != nil {
+		if _, statErr := os.Stat(repoPath); statErr != nil {
+			entry.State = CheckMissing
+			return entry
+		}
+		return drifted(fmt.Sprintf("not a %s repository", repo.Type))
+	}
+	if vcs.Type() != repo.Type {
+		return drifted(fmt.Sprintf("type '%s' != '%s'", vcs.Type(), repo.Type))
+	}
+
+	var url string
+	var versions []string
+	if vcs.Type() == "git" {
+		url, err = utils.GitRemoteURL(ctx, repoPath)
+		if err != nil {
+			return drifted("no origin remote")
+		}
+		versions, err = gitVersions(ctx, repoPath, repo.Version)
+	} else {
+		var current utils.Repository
+		current, err = vcs.Info(ctx, repoPath, false)
+		url, versions = current.URL, []string{current.Version}
+	}
+	if err != nil {
+		return drifted(err.Error())
+	}
+	if url != repo.URL {
+		drifted(fmt.Sprintf("url '%s' != '%s'", url, repo.URL))
+	}
+	if repo.Version != "" && !slices.Contains(versions, repo.Version) {
+		drifted(fmt.Sprintf("version '%s' != '%s'", strings.Join(versions, "', '"), repo.Version))
+	}
+	return entry
+}
+
+// gitVersions Get the current branch, the tags pointing at HEAD and, when the expected
+// version is a SHA, the commit of a git repository
+func gitVersions(ctx context.Context, repoPath string, expected string) ([]string, error) {
+	branch, err := utils.GitBranch(ctx, repoPath)
+	if err != nil {
+		return nil, err
+	}
+	versions := strings.Fields(branch)
+	// A branch can be checked out at the expected tag
+	tags, err := utils.RunGitCmdContext(ctx, repoPath, "tag", nil, "--points-at", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range strings.Fields(tags) {
+		if !slices.Contains(versions, tag) {
+			versions = append(versions, tag)
+		}
+	}
+	if utils.IsValidSha(expected) {
+		sha, err := utils.GitCommitSha(ctx, repoPath)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(sha, strings.ToLower(expected)) {
+			return append(versions, expected), nil
+		}
+		versions = append(versions, sha)
+	}
+	if len(versions) == 0 {
+		versions = []string{"detached HEAD"}
+	}
+	return versions, nil
+}
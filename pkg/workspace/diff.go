@@ -0,0 +1,151 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"ripvcs/utils"
+	"strings"
+)
+
+// DiffOptions Settings of a diff operation
+type DiffOptions struct {
+	// Staged Show the changes added to the index instead of the unstaged ones
+	Staged bool
+	// Stat Only show a summary of the changed files
+	Stat bool
+	// NameOnly Only show the names of the changed files
+	NameOnly bool
+	// Patch Produce patches that can be combined with CombinedPatch and applied with Apply
+	Patch bool
+}
+
+// ApplyOptions Settings of an apply operation
+type ApplyOptions struct {
+	// Patch Path to a combined patch created from the results of Diff
+	Patch string
+	// Check Only check that the patch applies without changing any file
+	Check bool
+}
+
+// Diff Get the uncommitted changes of the given repositories. The files of each diff are
+// prefixed with the path of the repository relative to the workspace root. Repositories
+// without changes are not reported
+func (w *Workspace) Diff(ctx context.Context, paths []string, opts DiffOptions) ([]Result, error) {
+	if opts.Patch && (opts.Stat || opts.NameOnly) {
+		return nil, fmt.Errorf("a patch can not be combined with stat or name-only")
+	}
+	results := w.forEach(ctx, paths, "diff", func(ctx context.Context, path string) *Result {
+		if !utils.IsGitRepository(path) {
+			return &Result{Path: path, Operation: "diff", Err: fmt.Errorf("diff is only supported for git repositories")}
+		}
+		output, err := utils.GitDiff(ctx, path, utils.GitDiffOptions{
+			Staged:   opts.Staged,
+			Stat:     opts.Stat,
+			NameOnly: opts.NameOnly,
+			Prefix:   w.relativePath(path),
+			Patch:    opts.Patch,
+		})
+		if err == nil && output == "" {
+			return nil
+		}
+		return &Result{Path: path, Operation: "diff", Success: err == nil, Output: output, Err: err}
+	})
+	return results, nil
+}
+
+// CombinedPatch Join the patches of the given diff results into a single patch
+func CombinedPatch(results []Result) string {
+	var patch strings.Builder
+	for _, result := range results {
+		if result.Success {
+			patch.WriteString(result.Output)
+		}
+	}
+	return patch.String()
+}
+
+// Apply Apply a combined patch to the repositories of the workspace. Every file of the
+// patch must belong to a repository found in the workspace. The patch is first checked
+// against every repository, and no repository is changed unless it applies to all of them
+func (w *Workspace) Apply(ctx context.Context, opts ApplyOptions) ([]Result, error) {
+	patch, err := os.ReadFile(opts.Patch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read patch %s. Error: %w", opts.Patch, err)
+	}
+	repoPaths, err := w.GitRepositories()
+	if err != nil {
+		return nil, err
+	}
+	pathsByName := make(map[string]string, len(repoPaths))
+	names := make([]string, 0, len(repoPaths))
+	for _, path := range repoPaths {
+		name := w.relativePath(path)
+		pathsByName[name] = path
+		names = append(names, name)
+	}
+	patches, err := utils.SplitCombinedPatch(string(patch), names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply %s to %s. Error: %w", opts.Patch, w.Root, err)
+	}
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("no changes found in %s", opts.Patch)
+	}
+
+	paths := make([]string, 0, len(patches))
+	for _, name := range sortedKeys(patches) {
+		paths = append(paths, pathsByName[name])
+	}
+	if opts.Check {
+		return w.applyPatches(ctx, paths, patches, true), nil
+	}
+
+	// Check every repository quietly first, so a patch is applied to all of them or to none
+	checker := *w
+	checker.Observer = nil
+	checks := checker.applyPatches(ctx, paths, patches, true)
+	failed := Failed(checks)
+	if len(failed) == 0 {
+		return w.applyPatches(ctx, paths, patches, false), nil
+	}
+	checked := make(map[string]Result, len(checks))
+	for _, result := range checks {
+		if result.Success {
+			result.Output = "Not applied, the patch does not apply to other repositories\n"
+			result.Skipped = true
+		}
+		checked[result.Path] = result
+	}
+	results := w.forEach(ctx, paths, "apply", func(ctx context.Context, path string) *Result {
+		result := checked[path]
+		return &result
+	})
+	return results, fmt.Errorf("%s does not apply to %d of %d repositories, no repository was changed", opts.Patch, len(failed), len(checks))
+}
+
+// applyPatches Apply, or only check, the patch of each repository
+func (w *Workspace) applyPatches(ctx context.Context, paths []string, patches map[string]string, check bool) []Result {
+	return w.forEach(ctx, paths, "apply", func(ctx context.Context, path string) *Result {
+		repoPatch := patches[w.relativePath(path)]
+		output, err := utils.GitApply(ctx, path, repoPatch, utils.PatchStripComponents(w.relativePath(path)), check)
+		if err == nil {
+			numFiles := utils.PatchFiles(repoPatch)
+			if check {
+				output += fmt.Sprintf("Patch applies cleanly to %d files\n", numFiles)
+			} else {
+				output += fmt.Sprintf("Applied changes to %d files\n", numFiles)
+			}
+		}
+		return &Result{Path: path, Operation: "apply", Success: err == nil, Output: output, Err: err}
+	})
+}
+
+// relativePath Get the path of a repository relative to the workspace root using forward slashes
+func (w *Workspace) relativePath(path string) string {
+	relPath, err := filepath.Rel(w.Root, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(relPath)
+}
@@ -0,0 +1,112 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"ripvcs/utils"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ExecOptions Settings of an exec operation
+type ExecOptions struct {
+	// Command Program and arguments to run. A single argument is run by the shell
+	Command []string
+	// Git Run the command as arguments of git
+	Git bool
+	// FailFast Skip the repositories not started yet once a command fails
+	FailFast bool
+	// OnlyFailures Only report the repositories where the command failed
+	OnlyFailures bool
+	// LogDir Directory where the output of each repository is written to
+	LogDir string
+}
+
+// ExecSummary Exit codes of the commands run by an exec operation
+type ExecSummary struct {
+	// ExitCodes Paths of the repositories grouped by exit code. Commands that could not be run have exit code -1
+	ExitCodes map[int][]string
+	// Skipped Paths of the repositories skipped after a failure
+	Skipped []string
+}
+
+// Exec Run a command in each of the given repositories
+func (w *Workspace) Exec(ctx context.Context, paths []string, opts ExecOptions) ([]Result, ExecSummary, error) {
+	summary := ExecSummary{ExitCodes: make(map[int][]string)}
+	if len(opts.Command) == 0 {
+		return nil, summary, fmt.Errorf("missing command to run")
+	}
+	name, args := opts.Command[0], opts.Command[1:]
+	switch {
+	case opts.Git:
+		name, args = "git", opts.Command
+	case len(opts.Command) == 1:
+		name, args = "sh", []string{"-c", opts.Command[0]}
+	}
+	if opts.LogDir != "" {
+		if err := os.MkdirAll(opts.LogDir, 0755); err != nil {
+			return nil, summary, fmt.Errorf("failed to create log directory %s. Error: %w", opts.LogDir, err)
+		}
+	}
+
+	var failed atomic.Bool
+	var summaryMutex sync.Mutex
+	results := w.forEach(ctx, paths, "exec", func(ctx context.Context, path string) *Result {
+		if opts.FailFast && failed.Load() {
+			summaryMutex.Lock()
+			summary.Skipped = append(summary.Skipped, path)
+			summaryMutex.Unlock()
+			if opts.OnlyFailures {
+				return nil
+			}
+			return &Result{Path: path, Operation: "exec", Success: true, Skipped: true, Output: "Skipped after a previous failure\n"}
+		}
+
+		output, err := utils.RunCommandContext(ctx, path, name, args...)
+		if err != nil && !Interrupted(Result{Err: err}) {
+			failed.Store(true)
+		}
+		summaryMutex.Lock()
+		summary.ExitCodes[output.ExitCode] = append(summary.ExitCodes[output.ExitCode], path)
+		summaryMutex.Unlock()
+
+		result := &Result{Path: path, Operation: "exec", Success: err == nil, Output: output.Stdout, Stderr: output.Stderr, ExitCode: &output.ExitCode, Err: err}
+		if opts.LogDir != "" {
+			if logErr := w.writeExecLog(opts.LogDir, path, append([]string{name}, args...), output); logErr != nil && err == nil {
+				result.Success = false
+				result.Err = logErr
+			}
+		}
+		if opts.OnlyFailures && result.Success {
+			return nil
+		}
+		return result
+	})
+	for _, codePaths := range summary.ExitCodes {
+		slices.Sort(codePaths)
+	}
+	slices.Sort(summary.Skipped)
+	return results, summary, nil
+}
+
+// writeExecLog Write the output of a command to a log file named after the repository path
+func (w *Workspace) writeExecLog(logDir string, path string, command []string, output utils.CommandOutput) error {
+	name := w.exportName(path)
+	logPath := filepath.Join(logDir, filepath.FromSlash(name)+".log")
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		return err
+	}
+	combined := output.Combined
+	if combined != "" && !strings.HasSuffix(combined, "\n") {
+		combined += "\n"
+	}
+	content := fmt.Sprintf("$ %s\n%sExit code: %d\n", strings.Join(command, " "), combined, output.ExitCode)
+	if err := os.WriteFile(logPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write log %s. Error: %w", logPath, err)
+	}
+	return nil
+}
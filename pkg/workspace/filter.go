@@ -0,0 +1,188 @@
+package workspace
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"regexp"
+	"ripvcs/utils"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Filter Selection of the repositories an operation runs on. The zero value selects all of them
+type Filter struct {
+	// Only Globs matching the path or name of the selected repositories
+	Only []string
+	// Skip Globs matching the path or name of the repositories to leave out
+	Skip []string
+	// URLMatch Expression matching the URL of the selected repositories
+	URLMatch *regexp.Regexp
+	// Branch Current branch of the selected repositories, or version of the selected manifest entries
+	Branch string
+	// Dirty Select repositories with (true) or without (false) uncommitted changes. Nil selects both
+	Dirty *bool
+	// ChangedSince Select git repositories whose working tree differs from this commit, branch or tag
+	ChangedSince string
+	// Groups Select repositories tagged with any of these groups
+	Groups []string
+	// SkipGroups Leave out repositories tagged with any of these groups
+	SkipGroups []string
+}
+
+// IsEmpty Check if the filter selects all the repositories
+func (f *Filter) IsEmpty() bool {
+	return f == nil || (len(f.Only) == 0 && len(f.Skip) == 0 && f.URLMatch == nil &&
+		f.Branch == "" && f.Dirty == nil && f.ChangedSince == "" && len(f.Groups) == 0 && len(f.SkipGroups) == 0)
+}
+
+// Select Get the repositories matched by the filter of the workspace
+func (w *Workspace) Select(ctx context.Context, paths []string) []string {
+	if w.Filter.IsEmpty() {
+		return paths
+	}
+	return w.selectMatching(ctx, paths, func(ctx context.Context, repoPath string) bool {
+		name := w.exportName(repoPath)
+		return w.Filter.matchName(name) && w.Filter.matchRepository(ctx, repoPath)
+	})
+}
+
+// selectEntries Get the entries of a .repos file matched by the filter of the workspace.
+// The URL and branch are matched against the entry. Entries not cloned yet are only
+// selected by the filters that do not need a working tree
+func (w *Workspace) selectEntries(ctx context.Context, repos map[string]utils.Repository) map[string]utils.Repository {
+	if w.Filter.IsEmpty() {
+		return repos
+	}
+	selected := w.selectMatching(ctx, sortedKeys(repos), func(ctx context.Context, dirName string) bool {
+		repo := repos[dirName]
+		if !w.Filter.matchName(dirName) || !w.Filter.matchURL(repo.URL) || !w.Filter.matchGroups(repo.Tags) ||
+			(w.Filter.Branch != "" && repo.Version != w.Filter.Branch) {
+			return false
+		}
+		repoPath := filepath.Join(w.Root, dirName)
+		if !utils.IsRepository(repoPath) {
+			return w.Filter.ChangedSince == "" && (w.Filter.Dirty == nil || !*w.Filter.Dirty)
+		}
+		return w.Filter.matchState(ctx, repoPath)
+	})
+	selectedRepos := make(map[string]utils.Repository, len(selected))
+	for _, dirName := range selected {
+		selectedRepos[dirName] = repos[dirName]
+	}
+	return selectedRepos
+}
+
+// selectMatching Concurrently check which of the given keys match
+func (w *Workspace) selectMatching(ctx context.Context, keys []string, match func(ctx context.Context, key string) bool) []string {
+	var selected []string
+	var mutex sync.Mutex
+	executor := utils.Executor{Workers: w.Workers, RepoTimeout: w.RepoTimeout}
+	executor.Run(ctx, keys, "select", func(ctx context.Context, key string) *Result {
+		if match(ctx, key) {
+			mutex.Lock()
+			selected = append(selected, key)
+			mutex.Unlock()
+		}
+		return nil
+	})
+	slices.Sort(selected)
+	return selected
+}
+
+// matchName Check the globs of the filter against a repository path, its name and its parent directories
+func (f *Filter) matchName(repoPath string) bool {
+	if len(f.Only) > 0 && !matchAnyGlob(f.Only, repoPath) {
+		return false
+	}
+	return !matchAnyGlob(f.Skip, repoPath)
+}
+
+// matchGroups Check the tags of a repository against the selected and skipped groups
+func (f *Filter) matchGroups(tags []string) bool {
+	if len(f.Groups) > 0 && !slices.ContainsFunc(f.Groups, func(group string) bool { return slices.Contains(tags, group) }) {
+		return false
+	}
+	return !slices.ContainsFunc(f.SkipGroups, func(group string) bool { return slices.Contains(tags, group) })
+}
+
+// matchURL Check if the URL of a repository matches the filter
+func (f *Filter) matchURL(url string) bool {
+	return f.URLMatch == nil || f.URLMatch.MatchString(url)
+}
+
+// matchRepository Check the URL, branch, tags and working tree of a repository against the filter
+func (f *Filter) matchRepository(ctx context.Context, repoPath string) bool {
+	if f.URLMatch != nil || f.Branch != "" {
+		url, branch, err := repositoryURLAndBranch(ctx, repoPath)
+		if err != nil || !f.matchURL(url) || (f.Branch != "" && branch != f.Branch) {
+			return false
+		}
+	}
+	if len(f.Groups) > 0 || len(f.SkipGroups) > 0 {
+		tags, err := utils.RepositoryTags(ctx, repoPath)
+		if err != nil || !f.matchGroups(tags) {
+			return false
+		}
+	}
+	return f.matchState(ctx, repoPath)
+}
+
+// matchState Check the working tree of a repository against the filter
+func (f *Filter) matchState(ctx context.Context, repoPath string) bool {
+	if f.Dirty != nil {
+		vcs, err := utils.DetectVCS(repoPath)
+		if err != nil {
+			return false
+		}
+		output, err := vcs.Status(ctx, repoPath, true)
+		if err != nil || vcs.IsCleanStatus(output, true) == *f.Dirty {
+			return false
+		}
+	}
+	if f.ChangedSince != "" {
+		if !utils.IsGitRepository(repoPath) {
+			return false
+		}
+		changed, err := utils.GitChangedSince(ctx, repoPath, f.ChangedSince)
+		if err != nil || !changed {
+			return false
+		}
+	}
+	return true
+}
+
+// repositoryURLAndBranch Get the remote URL and the current branch of a repository
+func repositoryURLAndBranch(ctx context.Context, repoPath string) (string, string, error) {
+	if utils.IsGitRepository(repoPath) {
+		// Repositories without remote can still be selected by branch
+		url, _ := utils.GitRemoteURL(ctx, repoPath)
+		branch, err := utils.GitBranch(ctx, repoPath)
+		return url, branch, err
+	}
+	vcs, err := utils.DetectVCS(repoPath)
+	if err != nil {
+		return "", "", err
+	}
+	repo, err := vcs.Info(ctx, repoPath, false)
+	return repo.URL, repo.Version, err
+}
+
+// matchAnyGlob Check if any of the globs matches the path, its base name or one of its parent directories
+func matchAnyGlob(globs []string, repoPath string) bool {
+	repoPath = strings.Trim(filepath.ToSlash(repoPath), "/")
+	candidates := []string{repoPath, path.Base(repoPath)}
+	for dir := path.Dir(repoPath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		candidates = append(candidates, dir)
+	}
+	for _, glob := range globs {
+		glob = strings.Trim(filepath.ToSlash(glob), "/")
+		for _, candidate := range candidates {
+			if matched, _ := path.Match(glob, candidate); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
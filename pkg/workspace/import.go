@@ -0,0 +1,316 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"ripvcs/utils"
+	"strings"
+	"sync"
+)
+
+// ImportOptions Settings of an import operation
+type ImportOptions struct {
+	// Input Path to the .repos file to import
+	Input string
+	// Recursive Import any other .repos file found in the cloned repositories
+	Recursive bool
+	// DepthRecursive Number of recursive import levels. -1 means no limit
+	DepthRecursive int
+	// Retries Number of attempts to clone each repository
+	Retries int
+	// OverwriteExisting Remove existing repositories before cloning them
+	OverwriteExisting bool
+	// Shallow Clone repositories with a depth of 1
+	Shallow bool
+	// RecurseSubmodules Clone submodules of the repositories
+	RecurseSubmodules bool
+	// EnablePrompt Allow git to prompt for credentials
+	EnablePrompt bool
+	// Exclude Files and/or directories to exclude when performing a recursive import
+	Exclude []string
+	// CacheDir Directory keeping a bare mirror of each repository, used as reference when cloning.
+	// Empty disables the cache
+	CacheDir string
+	// FromBundle Path to a bundle created by CreateBundle to import instead of Input.
+	// No network access is needed
+	FromBundle string
+	// Locked Check out the commits recorded in the lockfile of Input instead of its versions.
+	// All the repositories of the lockfile are imported and no other .repos file is searched
+	Locked bool
+}
+
+// Import Clone the repositories listed in a .repos file into the workspace root
+func (w *Workspace) Import(ctx context.Context, opts ImportOptions) ([]Result, error) {
+	if opts.FromBundle != "" {
+		return w.importBundle(ctx, opts)
+	}
+	if opts.Locked {
+		return w.importLocked(ctx, opts)
+	}
+	results, excludes, clonedPaths, err := w.importFile(ctx, opts.Input, opts)
+	if err != nil || !opts.Recursive {
+		return results, err
+	}
+	excludeList := append(append([]string{}, opts.Exclude...), excludes...)
+	nestedResults, err := w.importNested(ctx, opts, excludeList, clonedPaths)
+	return append(results, nestedResults...), err
+}
+
+// importFile Clone all the repositories of a single .repos file
+func (w *Workspace) importFile(ctx context.Context, filePath string, opts ImportOptions) ([]Result, []string, []string, error) {
+	w.notify(Event{Kind: ManifestStarted, Path: filePath, Operation: "import"})
+
+	config, err := utils.ParseReposFile(filePath)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid file given {%s}. %s", filePath, err)
+	}
+	return w.importRepositories(ctx, filePath, config, opts)
+}
+
+// importLocked Clone the repositories of the lockfile of a .repos file after checking both agree
+func (w *Workspace) importLocked(ctx context.Context, opts ImportOptions) ([]Result, error) {
+	lockPath := opts.Input + utils.LockFileSuffix
+	config, err := utils.ParseReposFile(opts.Input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file given {%s}. %s", opts.Input, err)
+	}
+	locked, err := utils.ParseReposFile(lockPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lockfile given {%s}. %s", lockPath, err)
+	}
+	if drift := LockDrift(config, locked); len(drift) > 0 {
+		return nil, fmt.Errorf("lockfile %s does not match %s. Run 'rv lock -i %s' to update it:\n  %s",
+			lockPath, opts.Input, opts.Input, strings.Join(drift, "\n  "))
+	}
+	w.notify(Event{Kind: ManifestStarted, Path: lockPath, Operation: "import"})
+	results, _, _, err := w.importRepositories(ctx, lockPath, locked, opts)
+	return results, err
+}
+
+// LockDrift Get the differences between the repositories of a .repos file and the ones it
+// has in its lockfile. Archives are compared by checksum, as the lockfile keeps them as they
+// are. Repositories the lockfile found in nested .repos files are ignored
+func LockDrift(config *utils.Config, locked *utils.Config) []string {
+	var drift []string
+	for _, dirName := range sortedKeys(config.Repositories) {
+		repo := config.Repositories[dirName]
+		lockedRepo, ok := locked.Repositories[dirName]
+		switch {
+		case !ok || lockedRepo.Source != "":
+			drift = append(drift, fmt.Sprintf("added: %s", dirName))
+		case repo.URL != lockedRepo.URL:
+			drift = append(drift, fmt.Sprintf("url changed: %s (%s -> %s)", dirName, lockedRepo.URL, repo.URL))
+		case repo.Type != lockedRepo.Type:
+			drift = append(drift, fmt.Sprintf("type changed: %s (%s -> %s)", dirName, lockedRepo.Type, repo.Type))
+		case isArchive(repo) && !strings.EqualFold(repo.SHA256, lockedRepo.SHA256):
+			drift = append(drift, fmt.Sprintf("sha256 changed: %s ('%s' -> '%s')", dirName, lockedRepo.SHA256, repo.SHA256))
+		case isArchive(repo) && repo.StripComponents != lockedRepo.StripComponents:
+			drift = append(drift, fmt.Sprintf("strip-components changed: %s (%d -> %d)", dirName, lockedRepo.StripComponents, repo.StripComponents))
+		case !isArchive(repo) && repo.Version != lockedRepo.Ref:
+			drift = append(drift, fmt.Sprintf("version changed: %s ('%s' -> '%s')", dirName, lockedRepo.Ref, repo.Version))
+		}
+	}
+	for _, dirName := range sortedKeys(locked.Repositories) {
+		if _, ok := config.Repositories[dirName]; !ok && locked.Repositories[dirName].Source == "" {
+			drift = append(drift, fmt.Sprintf("removed: %s", dirName))
+		}
+	}
+	return drift
+}
+
+// importRepositories Clone the repositories of a parsed .repos file
+func (w *Workspace) importRepositories(ctx context.Context, filePath string, config *utils.Config, opts ImportOptions) ([]Result, []string, []string, error) {
+	var allExcludes []string
+	var clonedPaths []string
+	var mutex sync.Mutex
+
+	selected := w.selectEntries(ctx, config.Repositories)
+	paths := make([]string, 0, len(selected))
+	repos := make(map[string]utils.Repository, len(selected))
+	for dirName, repo := range selected {
+		repoPath := filepath.Join(w.Root, dirName)
+		paths = append(paths, repoPath)
+		repos[repoPath] = repo
+	}
+
+	results := w.forEach(ctx, paths, "import", func(ctx context.Context, repoPath string) *Result {
+		repo := repos[repoPath]
+		result := cloneRepository(ctx, repoPath, repo, opts)
+		mutex.Lock()
+		defer mutex.Unlock()
+		if result.Success {
+			clonedPaths = append(clonedPaths, repoPath)
+		}
+		allExcludes = append(allExcludes, repo.Exclude...)
+		return result
+	})
+
+	if err := ctx.Err(); err != nil {
+		return results, allExcludes, clonedPaths, fmt.Errorf("interrupted while cloning %s: %w", filePath, err)
+	}
+	if len(Failed(results)) > 0 {
+		return results, allExcludes, clonedPaths, fmt.Errorf("failed while cloning %s", filePath)
+	}
+	return results, allExcludes, clonedPaths, nil
+}
+
+// cloneRepository Clone a single repository retrying on failures
+func cloneRepository(ctx context.Context, repoPath string, repo utils.Repository, opts ImportOptions) *Result {
+	result := &Result{Path: repoPath, Operation: "import", Repository: &repo}
+	vcs, err := utils.GetVCS(repo.Type)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	numRetries := max(opts.Retries, 1)
+	var statusClone int
+	for range numRetries {
+		statusClone, err = vcs.Clone(ctx, utils.CloneOptions{
+			URL:               repo.URL,
+			Version:           repo.Version,
+			Path:              repoPath,
+			OverwriteExisting: opts.OverwriteExisting,
+			Shallow:           opts.Shallow,
+			EnablePrompt:      opts.EnablePrompt,
+			RecurseSubmodules: opts.RecurseSubmodules,
+			CacheDir:          opts.CacheDir,
+			SHA256:            repo.SHA256,
+			StripComponents:   repo.StripComponents,
+		})
+		if statusClone != utils.FailedClone || ctx.Err() != nil {
+			break
+		}
+	}
+
+	switch statusClone {
+	case utils.SuccessfullClone:
+		result.Output = fmt.Sprintf("Successfully cloned %s repository '%s' with version '%s'\n", repo.Type, repo.URL, repo.Version)
+		result.Success = true
+	case utils.SkippedClone:
+		result.Output = fmt.Sprintf("Skipped cloning existing %s repository '%s'\n", repo.Type, repo.URL)
+		result.Success = true
+		result.Skipped = true
+	case utils.SwitchedBranch:
+		result.Output = fmt.Sprintf("Successfully switched to version '%s' in existing %s repository '%s'\n", repo.Version, repo.Type, repo.URL)
+		result.Success = true
+	default:
+		result.Output = fmt.Sprintf("Failed to clone %s repository '%s' with version '%s'\n", repo.Type, repo.URL, repo.Version)
+		result.Err = err
+	}
+	recordTags(ctx, repoPath, repo, result)
+	addRemotes(ctx, repoPath, repo, true, opts.EnablePrompt, result)
+	return result
+}
+
+// recordTags Record the tags of an imported repository so they are kept by export and selection.
+// Extracted archives are not repositories of the workspace, so their tags only select the
+// entries of .repos files
+func recordTags(ctx context.Context, repoPath string, repo utils.Repository, result *Result) {
+	if !result.Success || !utils.IsRepository(repoPath) {
+		return
+	}
+	if err := utils.SetRepositoryTags(ctx, repoPath, repo.Tags); err != nil {
+		result.Success = false
+		result.Skipped = false
+		result.Err = err
+	}
+}
+
+// addRemotes Add the remotes listed besides origin to an imported git repository. The remotes
+// added or whose URL changed are fetched when requested
+func addRemotes(ctx context.Context, repoPath string, repo utils.Repository, fetch bool, enablePrompt bool, result *Result) {
+	if !result.Success || repo.Type != "git" {
+		return
+	}
+	for _, name := range sortedKeys(repo.Remotes) {
+		if name == "origin" {
+			continue
+		}
+		changed, err := utils.GitSetRemote(ctx, repoPath, name, repo.Remotes[name])
+		if err == nil && changed && fetch {
+			err = utils.GitFetchRemote(ctx, repoPath, name, enablePrompt)
+		}
+		if err != nil {
+			result.Success = false
+			result.Skipped = false
+			result.Err = err
+			return
+		}
+	}
+}
+
+// importNested Recursively import the .repos files found in the cloned repositories
+func (w *Workspace) importNested(ctx context.Context, opts ImportOptions, excludeList []string, clonedPaths []string) ([]Result, error) {
+	var results []Result
+	clonedReposFiles := map[string]bool{opts.Input: true}
+	cloneSweepCounter := 0
+
+	numPreviousFoundReposFiles := 0
+
+	for {
+		// Check if recursion level has been reached
+		if opts.DepthRecursive != -1 && cloneSweepCounter >= opts.DepthRecursive {
+			break
+		}
+
+		// Find .repos file to clone
+		foundReposFiles, err := utils.FindReposFilesIgnoring(w.Root, clonedPaths, w.Discovery.Ignore)
+		if err != nil || len(foundReposFiles) == 0 {
+			break
+		}
+
+		if len(foundReposFiles) == numPreviousFoundReposFiles {
+			break
+		}
+		numPreviousFoundReposFiles = len(foundReposFiles)
+
+		// Get dependencies to clone
+		newReposFileFound := false
+
+		for _, filePathToClone := range foundReposFiles {
+			if _, ok := clonedReposFiles[filePathToClone]; ok {
+				continue
+			}
+			if isExcluded(filePathToClone, excludeList) {
+				w.notify(Event{Kind: ManifestExcluded, Path: filePathToClone, Operation: "import"})
+				clonedReposFiles[filePathToClone] = false
+				continue
+			}
+			fileResults, excludes, newClonedPaths, err := w.importFile(ctx, filePathToClone, opts)
+			results = append(results, fileResults...)
+			clonedReposFiles[filePathToClone] = true
+			newReposFileFound = true
+			clonedPaths = append(clonedPaths, newClonedPaths...)
+			if err != nil {
+				return results, err
+			}
+			excludeList = append(excludeList, excludes...)
+		}
+		if !newReposFileFound {
+			break
+		}
+		cloneSweepCounter++
+	}
+	return results, nil
+}
+
+// isExcluded Check if a .repos file matches any of the exclude entries
+func isExcluded(filePath string, excludeList []string) bool {
+	filePathBase := filepath.Base(filePath)
+	filePathParentDir := filepath.Base(filepath.Dir(filePath))
+
+	for _, excludePath := range excludeList {
+		excludeBase := filepath.Base(excludePath)
+
+		// Check if exclude matches either:
+		// 1. The full relative path
+		// 2. The filename
+		// 3. The parent directory
+		if filePathBase == excludeBase || filePathParentDir == excludeBase || strings.HasPrefix(filePath, excludePath) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"ripvcs/utils"
+	"slices"
+	"sync"
+)
+
+// LockOptions Settings of a lock operation
+type LockOptions struct {
+	// Input Path to the .repos file to lock
+	Input string
+	// Recursive Also lock the repositories of the .repos files found in the locked repositories
+	Recursive bool
+	// Exclude Files and/or directories to exclude when performing a recursive lock
+	Exclude []string
+	// EnablePrompt Allow the version control system to prompt for credentials
+	EnablePrompt bool
+}
+
+// Lock Resolve the version of every repository listed in a .repos file to a git commit SHA,
+// a Mercurial node id or a Subversion revision. Archives are kept as they are. The original
+// version is kept as the ref of each locked repository. Results are keyed by the name of the
+// repository in the .repos file. Only git repositories are searched for nested .repos files.
+func (w *Workspace) Lock(ctx context.Context, opts LockOptions) (*utils.Config, []Result, error) {
+	config, err := utils.ParseReposFile(opts.Input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid file given {%s}. %s", opts.Input, err)
+	}
+
+	config.Repositories = w.selectEntries(ctx, config.Repositories)
+	locked := &utils.Config{Repositories: make(map[string]utils.Repository)}
+	results, excludes := w.lockRepositories(ctx, config.Repositories, "", opts, locked)
+	pending := sortedKeys(config.Repositories)
+	excludeList := append(append([]string{}, opts.Exclude...), excludes...)
+
+	for opts.Recursive && len(pending) > 0 && ctx.Err() == nil && len(Failed(results)) == 0 {
+		nested, nestedSources, err := w.findNestedRepositories(ctx, pending, locked, excludeList)
+		if err != nil {
+			return locked, results, err
+		}
+		pending = nil
+		for _, source := range sortedKeys(nestedSources) {
+			repos := nestedSources[source]
+			newRepos := make(map[string]utils.Repository)
+			for _, dirName := range repos {
+				if _, ok := locked.Repositories[dirName]; ok {
+					continue
+				}
+				newRepos[dirName] = nested[dirName]
+			}
+			newRepos = w.selectEntries(ctx, newRepos)
+			pending = append(pending, sortedKeys(newRepos)...)
+			nestedResults, excludes := w.lockRepositories(ctx, newRepos, source, opts, locked)
+			results = append(results, nestedResults...)
+			excludeList = append(excludeList, excludes...)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return locked, results, fmt.Errorf("interrupted while locking %s: %w", opts.Input, err)
+	}
+	if len(Failed(results)) > 0 {
+		return locked, results, fmt.Errorf("failed while locking %s", opts.Input)
+	}
+	return locked, results, nil
+}
+
+// lockRepositories Resolve the version of the given repositories and add them to the locked config
+func (w *Workspace) lockRepositories(ctx context.Context, repos map[string]utils.Repository, source string, opts LockOptions, locked *utils.Config) ([]Result, []string) {
+	var excludes []string
+	var mutex sync.Mutex
+
+	results := w.forEach(ctx, sortedKeys(repos), "lock", func(ctx context.Context, dirName string) *Result {
+		repo := repos[dirName]
+		result := &Result{Path: dirName, Operation: "lock", Repository: &repo}
+		lockedRepo := repo
+		lockedRepo.Source = source
+		if isArchive(repo) {
+			// The checksum of an archive already pins its content
+			result.Output = fmt.Sprintf("Kept %s archive '%s'\n", repo.Type, repo.URL)
+		} else {
+			version, err := resolveVersion(ctx, repo, opts.EnablePrompt)
+			if err != nil {
+				result.Err = err
+				return result
+			}
+			lockedRepo.Ref = repo.Version
+			lockedRepo.Version = version
+			result.Output = fmt.Sprintf("Locked '%s' to %s\n", repo.Version, version)
+		}
+		result.Repository = &lockedRepo
+		result.Success = true
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		locked.Repositories[dirName] = lockedRepo
+		excludes = append(excludes, repo.Exclude...)
+		return result
+	})
+	return results, excludes
+}
+
+// resolveVersion Get the commit, node id or revision the version of a repository points to
+func resolveVersion(ctx context.Context, repo utils.Repository, enablePrompt bool) (string, error) {
+	switch repo.Type {
+	case "git":
+		return utils.ResolveGitVersion(ctx, repo.URL, repo.Version, enablePrompt)
+	case "hg":
+		return utils.ResolveHgVersion(ctx, repo.URL, repo.Version, enablePrompt)
+	case "svn":
+		return utils.ResolveSvnVersion(ctx, repo.URL, repo.Version, enablePrompt)
+	}
+	return "", fmt.Errorf("unsupported repository type %s", repo.Type)
+}
+
+// isArchive Check if a repository is a tar or zip archive
+func isArchive(repo utils.Repository) bool {
+	return repo.Type == "tar" || repo.Type == "zip"
+}
+
+// findNestedRepositories Fetch the locked commit of the given git repositories and parse the
+// .repos files they contain. Repositories are returned grouped by the .repos file listing them
+func (w *Workspace) findNestedRepositories(ctx context.Context, dirNames []string, locked *utils.Config, excludeList []string) (map[string]utils.Repository, map[string][]string, error) {
+	tempDir, err := os.MkdirTemp("", "rv-lock-")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	nested := make(map[string]utils.Repository)
+	sources := make(map[string][]string)
+	for _, dirName := range dirNames {
+		repo := locked.Repositories[dirName]
+		if repo.Type != "git" {
+			continue
+		}
+		fetchPath := filepath.Join(tempDir, dirName)
+		if err := utils.FetchGitCommit(ctx, repo.URL, repo.Version, fetchPath); err != nil {
+			return nil, nil, err
+		}
+		reposFiles, err := utils.FindReposFiles(fetchPath, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, reposFile := range reposFiles {
+			relPath, _ := filepath.Rel(fetchPath, reposFile)
+			source := filepath.ToSlash(filepath.Join(dirName, relPath))
+			if isExcluded(source, excludeList) {
+				w.notify(Event{Kind: ManifestExcluded, Path: source, Operation: "lock"})
+				continue
+			}
+			config, err := utils.ParseReposFile(reposFile)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid file given {%s}. %s", source, err)
+			}
+			for _, nestedName := range sortedKeys(config.Repositories) {
+				if _, ok := nested[nestedName]; ok {
+					continue
+				}
+				nested[nestedName] = config.Repositories[nestedName]
+				sources[source] = append(sources[source], nestedName)
+			}
+		}
+	}
+	return nested, sources, nil
+}
+
+// sortedKeys Get the keys of a map in order
+func sortedKeys[V any](values map[string]V) []string {
+	return slices.Sorted(maps.Keys(values))
+}
@@ -0,0 +1,126 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"ripvcs/utils"
+	"slices"
+)
+
+// ManifestOptions Settings used to resolve the repositories a .repos file expects in the workspace
+type ManifestOptions struct {
+	// Input Path to the .repos file listing the repositories
+	Input string
+	// Recursive Follow the .repos files found in the listed repositories, as a recursive import does
+	Recursive bool
+	// Exclude Files and/or directories to exclude when following .repos files recursively
+	Exclude []string
+}
+
+// Manifest Repositories of the workspace compared to the ones listed in a .repos file
+type Manifest struct {
+	// Repositories Listed entries keyed by name, including the ones of followed .repos files
+	Repositories map[string]utils.Repository
+	// Paths Paths of the listed repositories found in the workspace
+	Paths []string
+	// Missing Paths of the listed repositories not found in the workspace
+	Missing []string
+	// Stray Paths of the repositories found in the workspace that are not listed
+	Stray []string
+}
+
+// ResolveManifest Get the repositories of the workspace listed in a .repos file, the listed ones
+// that are missing and the ones found that are not listed. The filter of the workspace applies
+// to all of them
+func (w *Workspace) ResolveManifest(ctx context.Context, opts ManifestOptions) (*Manifest, error) {
+	config, err := utils.ParseReposFile(opts.Input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file given {%s}. %s", opts.Input, err)
+	}
+	repos := make(map[string]utils.Repository, len(config.Repositories))
+	excludeList := append([]string{}, opts.Exclude...)
+	pending := addManifestEntries(repos, config, &excludeList)
+
+	visitedFiles := map[string]bool{opts.Input: true}
+	for opts.Recursive && len(pending) > 0 {
+		var existingPaths []string
+		for _, dirName := range pending {
+			if repoPath := filepath.Join(w.Root, dirName); utils.IsRepository(repoPath) {
+				existingPaths = append(existingPaths, repoPath)
+			}
+		}
+		if len(existingPaths) == 0 {
+			break
+		}
+		// Without paths FindReposFiles would search the whole workspace
+		reposFiles, err := utils.FindReposFilesIgnoring(w.Root, existingPaths, w.Discovery.Ignore)
+		if err != nil {
+			return nil, err
+		}
+		pending = nil
+		for _, reposFile := range reposFiles {
+			if visitedFiles[reposFile] {
+				continue
+			}
+			visitedFiles[reposFile] = true
+			if isExcluded(reposFile, excludeList) {
+				w.notify(Event{Kind: ManifestExcluded, Path: reposFile, Operation: "manifest"})
+				continue
+			}
+			nested, err := utils.ParseReposFile(reposFile)
+			if err != nil {
+				return nil, fmt.Errorf("invalid file given {%s}. %s", reposFile, err)
+			}
+			pending = append(pending, addManifestEntries(repos, nested, &excludeList)...)
+		}
+	}
+
+	manifest := &Manifest{Repositories: w.selectEntries(ctx, repos)}
+	listed := make(map[string]bool, len(repos))
+	listedPaths := make([]string, 0, len(repos))
+	for _, dirName := range sortedKeys(repos) {
+		repoPath := filepath.Join(w.Root, dirName)
+		listed[filepath.Clean(repoPath)] = true
+		listedPaths = append(listedPaths, repoPath)
+		if _, ok := manifest.Repositories[dirName]; !ok {
+			continue
+		}
+		if utils.IsRepository(repoPath) {
+			manifest.Paths = append(manifest.Paths, repoPath)
+		} else {
+			manifest.Missing = append(manifest.Missing, repoPath)
+		}
+	}
+
+	found, err := w.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	var stray []string
+	for _, repoPath := range found {
+		// Submodule checkouts belong to the listed repository they are part of
+		if !listed[filepath.Clean(repoPath)] && !updatedByParent(repoPath, listedPaths) {
+			stray = append(stray, repoPath)
+		}
+	}
+	manifest.Stray = w.Select(ctx, stray)
+	slices.Sort(manifest.Stray)
+	return manifest, nil
+}
+
+// addManifestEntries Add the entries of a .repos file not listed yet and collect their excludes.
+// Returns the names of the added entries
+func addManifestEntries(repos map[string]utils.Repository, config *utils.Config, excludeList *[]string) []string {
+	var added []string
+	for _, dirName := range sortedKeys(config.Repositories) {
+		if _, ok := repos[dirName]; ok {
+			continue
+		}
+		repo := config.Repositories[dirName]
+		repos[dirName] = repo
+		*excludeList = append(*excludeList, repo.Exclude...)
+		added = append(added, dirName)
+	}
+	return added
+}
@@ -0,0 +1,69 @@
+package workspace
+
+import (
+	"fmt"
+	"ripvcs/utils"
+)
+
+// Kinds of change of a repository between two .repos files
+const (
+	ManifestAdded   = "added"
+	ManifestRemoved = "removed"
+	ManifestChanged = "changed"
+)
+
+// ManifestChange Difference of a single repository between two .repos files
+type ManifestChange struct {
+	Name   string            `json:"name"`
+	Change string            `json:"change"`
+	Old    *utils.Repository `json:"old,omitempty"`
+	New    *utils.Repository `json:"new,omitempty"`
+	// Details Changed fields of a changed repository, e.g. "version: main -> jazzy"
+	Details []string `json:"details,omitempty"`
+}
+
+// DiffManifests Get the repositories added, removed or changed between two .repos files, sorted by name
+func DiffManifests(oldConfig *utils.Config, newConfig *utils.Config) []ManifestChange {
+	var changes []ManifestChange
+	names := make(map[string]struct{}, len(oldConfig.Repositories)+len(newConfig.Repositories))
+	for name := range oldConfig.Repositories {
+		names[name] = struct{}{}
+	}
+	for name := range newConfig.Repositories {
+		names[name] = struct{}{}
+	}
+
+	for _, name := range sortedKeys(names) {
+		oldRepo, inOld := oldConfig.Repositories[name]
+		newRepo, inNew := newConfig.Repositories[name]
+		switch {
+		case !inOld:
+			changes = append(changes, ManifestChange{Name: name, Change: ManifestAdded, New: &newRepo})
+		case !inNew:
+			changes = append(changes, ManifestChange{Name: name, Change: ManifestRemoved, Old: &oldRepo})
+		default:
+			var details []string
+			if oldRepo.Type != newRepo.Type {
+				details = append(details, fmt.Sprintf("type: %s -> %s", oldRepo.Type, newRepo.Type))
+			}
+			if oldRepo.URL != newRepo.URL {
+				details = append(details, fmt.Sprintf("url: %s -> %s", oldRepo.URL, newRepo.URL))
+			}
+			if oldRepo.Version != newRepo.Version {
+				details = append(details, fmt.Sprintf("version: %s -> %s", versionOrDefault(oldRepo.Version), versionOrDefault(newRepo.Version)))
+			}
+			if len(details) > 0 {
+				changes = append(changes, ManifestChange{Name: name, Change: ManifestChanged, Old: &oldRepo, New: &newRepo, Details: details})
+			}
+		}
+	}
+	return changes
+}
+
+// versionOrDefault Describe an empty version as the default branch
+func versionOrDefault(version string) string {
+	if version == "" {
+		return "(default)"
+	}
+	return version
+}
@@ -0,0 +1,264 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"ripvcs/utils"
+	"slices"
+	"strings"
+)
+
+// StatusOptions Settings of a status operation
+type StatusOptions struct {
+	// Plain Show a short status report
+	Plain bool
+	// SkipEmpty Omit repositories with a clean working tree
+	SkipEmpty bool
+	// Tracking Show the remote branch tracked by the current branch of git repositories
+	Tracking bool
+}
+
+// LogOptions Settings of a log operation
+type LogOptions struct {
+	// Oneline Show a short version of the logs
+	Oneline bool
+	// NumCommits Number of commits to show
+	NumCommits int
+}
+
+// SwitchOptions Settings of a switch operation
+type SwitchOptions struct {
+	// Version Branch, tag or commit to switch to
+	Version string
+	// Create Create the branch before switching to it
+	Create bool
+	// Detach Detach HEAD at the given version
+	Detach bool
+}
+
+// PullOptions Settings of a pull or sync operation
+type PullOptions struct {
+	// RecurseSubmodules Also update the submodules of git repositories to the commits they record
+	RecurseSubmodules bool
+}
+
+// ExportOptions Settings of an export operation
+type ExportOptions struct {
+	// UseCommits Export commit hashes instead of branches
+	UseCommits bool
+	// ExactWithTags Export the tag pointing at HEAD, or the commit hash when there is none
+	ExactWithTags bool
+	// Strict Fail for git repositories with uncommitted changes, unpushed commits or a version
+	// missing on origin
+	Strict bool
+}
+
+// ValidateOptions Settings of a validate operation
+type ValidateOptions struct {
+	// EnablePrompt Allow the version control system to prompt for credentials
+	EnablePrompt bool
+}
+
+// Status Get the status of the given repositories
+func (w *Workspace) Status(ctx context.Context, paths []string, opts StatusOptions) []Result {
+	return w.forEach(ctx, paths, "status", func(ctx context.Context, path string) *Result {
+		vcs, err := utils.DetectVCS(path)
+		if err != nil {
+			return &Result{Path: path, Operation: "status", Err: err}
+		}
+		output, err := vcs.Status(ctx, path, opts.Plain)
+		var submodules string
+		if err == nil && vcs.Type() == "git" {
+			submodules, err = submoduleReport(ctx, path)
+		}
+		if err == nil && opts.SkipEmpty && vcs.IsCleanStatus(output, opts.Plain) && submodules == "" {
+			return nil
+		}
+		output += submodules
+		if err == nil && opts.Tracking && vcs.Type() == "git" {
+			var upstream string
+			upstream, err = utils.GitUpstream(ctx, path)
+			if upstream == "" {
+				upstream = "none"
+			}
+			output = fmt.Sprintf("Tracking: %s\n%s", upstream, output)
+		}
+		return &Result{Path: path, Operation: "status", Success: err == nil, Output: output, Err: err}
+	})
+}
+
+// Log Get the logs of the given repositories
+func (w *Workspace) Log(ctx context.Context, paths []string, opts LogOptions) []Result {
+	return w.forEach(ctx, paths, "log", func(ctx context.Context, path string) *Result {
+		vcs, err := utils.DetectVCS(path)
+		if err != nil {
+			return &Result{Path: path, Operation: "log", Err: err}
+		}
+		output, err := vcs.Log(ctx, path, opts.Oneline, opts.NumCommits)
+		return &Result{Path: path, Operation: "log", Success: err == nil, Output: output, Err: err}
+	})
+}
+
+// Pull Pull the latest version from the remote of the given repositories
+func (w *Workspace) Pull(ctx context.Context, paths []string, opts PullOptions) []Result {
+	return w.forEach(ctx, paths, "pull", func(ctx context.Context, path string) *Result {
+		vcs, err := utils.DetectVCS(path)
+		if err != nil {
+			return &Result{Path: path, Operation: "pull", Err: err}
+		}
+		if vcs.Type() == "git" && updatedByParent(path, paths) {
+			return skippedSubmodule(path, "pull")
+		}
+		if opts.RecurseSubmodules && vcs.Type() == "git" {
+			output, err := utils.GitPull(ctx, path, "--recurse-submodules")
+			return &Result{Path: path, Operation: "pull", Success: err == nil, Output: output, Err: err}
+		}
+		output, err := vcs.Pull(ctx, path)
+		return &Result{Path: path, Operation: "pull", Success: err == nil, Output: output, Err: err}
+	})
+}
+
+// Sync Stash local changes, pull the latest remote and restore the changes of the given repositories
+func (w *Workspace) Sync(ctx context.Context, paths []string, opts PullOptions) []Result {
+	return w.forEach(ctx, paths, "sync", func(ctx context.Context, path string) *Result {
+		if !utils.IsGitRepository(path) {
+			return &Result{Path: path, Operation: "sync", Err: fmt.Errorf("sync is only supported for git repositories")}
+		}
+		if updatedByParent(path, paths) {
+			return skippedSubmodule(path, "sync")
+		}
+		var pullArgs []string
+		if opts.RecurseSubmodules {
+			pullArgs = append(pullArgs, "--recurse-submodules")
+		}
+		output, err := utils.GitSync(ctx, path, pullArgs...)
+		return &Result{Path: path, Operation: "sync", Success: err == nil, Output: output, Err: err}
+	})
+}
+
+// Switch Switch the version of a single repository
+func Switch(ctx context.Context, path string, opts SwitchOptions) Result {
+	if !utils.IsGitRepository(path) {
+		return Result{Path: path, Operation: "switch", Err: fmt.Errorf("switch is only supported for git repositories")}
+	}
+	output, err := utils.GitSwitchContext(ctx, path, opts.Version, opts.Create, opts.Detach)
+	return Result{Path: path, Operation: "switch", Success: err == nil, Output: output, Err: err}
+}
+
+// ErrExportConflict Two repositories would be exported under the same name
+var ErrExportConflict = errors.New("conflicting export name")
+
+// ErrUnpublished The exported version of a repository cannot be reproduced from its remote
+var ErrUnpublished = errors.New("version cannot be reproduced from origin")
+
+// Export Collect the information of the given repositories into a Config. Repositories are keyed
+// by their path relative to the root of the workspace, so a re-import restores the same layout.
+// Repositories that would be exported under the same name fail with ErrExportConflict
+func (w *Workspace) Export(ctx context.Context, paths []string, opts ExportOptions) (*utils.Config, []Result) {
+	results := w.forEach(ctx, paths, "export", func(ctx context.Context, path string) *Result {
+		repo, err := utils.ReadRepositoryInfo(ctx, path, opts.UseCommits || opts.ExactWithTags)
+		if err == nil && repo.Type == "git" {
+			err = exportGitVersion(ctx, path, &repo, opts)
+		}
+		result := &Result{Path: path, Operation: "export", Success: err == nil, Err: err}
+		if err == nil {
+			result.Output = fmt.Sprintf("%s %s\n", repo.URL, repo.Version)
+			result.Repository = &repo
+		}
+		return result
+	})
+
+	exported := make(map[string][]int)
+	for i, result := range results {
+		if result.Success {
+			name := w.exportName(result.Path)
+			exported[name] = append(exported[name], i)
+		}
+	}
+	config := &utils.Config{Repositories: make(map[string]utils.Repository, len(exported))}
+	for _, name := range sortedKeys(exported) {
+		indexes := exported[name]
+		if len(indexes) == 1 {
+			config.Repositories[name] = *results[indexes[0]].Repository
+			continue
+		}
+		conflicting := make([]string, 0, len(indexes))
+		for _, i := range indexes {
+			conflicting = append(conflicting, results[i].Path)
+		}
+		slices.Sort(conflicting)
+		for _, i := range indexes {
+			results[i].Success = false
+			results[i].Output = ""
+			results[i].Err = fmt.Errorf("%w '%s' for %s", ErrExportConflict, name, strings.Join(conflicting, ", "))
+		}
+	}
+	return config, results
+}
+
+// exportGitVersion Resolve the exported version of a git repository and check it in strict mode
+func exportGitVersion(ctx context.Context, path string, repo *utils.Repository, opts ExportOptions) error {
+	// A detached HEAD, like the one of a submodule, has no branch to export
+	if repo.Version == "" {
+		sha, err := utils.GitCommitSha(ctx, path)
+		if err != nil {
+			return err
+		}
+		repo.Version = sha
+	}
+	if opts.ExactWithTags {
+		tag, err := utils.GitExactTag(ctx, path)
+		if err != nil {
+			return err
+		}
+		if tag != "" {
+			repo.Version = tag
+		}
+	}
+	if !opts.Strict {
+		return nil
+	}
+	reasons, err := utils.GitUnpublished(ctx, path, repo.Version)
+	if err != nil {
+		return err
+	}
+	if len(reasons) > 0 {
+		return fmt.Errorf("%s: %w: %s", path, ErrUnpublished, strings.Join(reasons, ", "))
+	}
+	return nil
+}
+
+// exportName Get the name used to store a repository in an exported Config: its path
+// relative to the root, or the name of the directory for the root itself
+func (w *Workspace) exportName(path string) string {
+	name := w.relativePath(path)
+	if name == "." {
+		absPath, _ := filepath.Abs(path)
+		return filepath.Base(absPath)
+	}
+	return name
+}
+
+// Validate Check that the repositories of the given config are reachable
+func (w *Workspace) Validate(ctx context.Context, config *utils.Config, opts ValidateOptions) []Result {
+	repos := w.selectEntries(ctx, config.Repositories)
+	return w.forEach(ctx, sortedKeys(repos), "validate", func(ctx context.Context, name string) *Result {
+		repo := repos[name]
+		result := &Result{Path: name, Operation: "validate", Repository: &repo}
+		vcs, err := utils.GetVCS(repo.Type)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		if err := vcs.Validate(ctx, repo, opts.EnablePrompt); err != nil {
+			result.Output = fmt.Sprintf("Failed to contact %s repository '%s' with version '%s'\n", repo.Type, repo.URL, repo.Version)
+			result.Err = err
+			return result
+		}
+		result.Success = true
+		result.Output = fmt.Sprintf("Successfully contact %s repository '%s' with version '%s'\n", repo.Type, repo.URL, repo.Version)
+		return result
+	})
+}
@@ -0,0 +1,79 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"ripvcs/utils"
+	"strings"
+)
+
+// SubmoduleOptions Settings of a submodule update operation
+type SubmoduleOptions struct {
+	// Recursive Also update the submodules of the submodules
+	Recursive bool
+}
+
+// SubmoduleUpdate Initialize the submodules of the given git repositories and check out the
+// commits they record. Repositories without submodules are not reported
+func (w *Workspace) SubmoduleUpdate(ctx context.Context, paths []string, opts SubmoduleOptions) []Result {
+	return w.forEach(ctx, paths, "submodule update", func(ctx context.Context, path string) *Result {
+		if !utils.IsGitRepository(path) || !hasSubmodules(path) {
+			return nil
+		}
+		if opts.Recursive && updatedByParent(path, paths) {
+			return skippedSubmodule(path, "submodule update")
+		}
+		output, err := utils.GitSubmoduleUpdate(ctx, path, opts.Recursive)
+		if err == nil && output == "" {
+			output = "Submodules up to date\n"
+		}
+		return &Result{Path: path, Operation: "submodule update", Success: err == nil, Output: output, Err: err}
+	})
+}
+
+// submoduleReport Describe the submodules of a git repository that are not clean. Empty when all are
+func submoduleReport(ctx context.Context, path string) (string, error) {
+	submodules, err := utils.GitSubmodules(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	var report strings.Builder
+	for _, submodule := range submodules {
+		if submodule.State != utils.SubmoduleClean {
+			fmt.Fprintf(&report, "  %s: %s\n", submodule.Path, submodule.State)
+		}
+	}
+	if report.Len() == 0 {
+		return "", nil
+	}
+	return "Submodules:\n" + report.String(), nil
+}
+
+// hasSubmodules Check if a git repository declares submodules
+func hasSubmodules(path string) bool {
+	_, err := os.Stat(filepath.Join(path, ".gitmodules"))
+	return err == nil
+}
+
+// updatedByParent Check if a path is the checkout of a submodule of another of the given
+// repositories, which manages it and updates it when recursing into its submodules
+func updatedByParent(path string, paths []string) bool {
+	if !utils.IsGitSubmodule(path) {
+		return false
+	}
+	for _, parent := range paths {
+		relPath, err := filepath.Rel(parent, path)
+		if err == nil && relPath != "." && filepath.IsLocal(relPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// skippedSubmodule Result of a submodule left to the operation on its parent repository
+func skippedSubmodule(path string, operation string) *Result {
+	return &Result{Path: path, Operation: operation, Success: true, Skipped: true,
+		Output: "Skipped submodule, managed by its parent repository\n"}
+}
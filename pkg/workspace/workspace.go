@@ -0,0 +1,160 @@
+// Package workspace provides a programmatic API to manage workspaces made of
+// multiple repositories.
+//
+// Operations return typed results and errors instead of printing, which
+// allows using ripvcs from other Go tools. Progress can be followed by
+// providing an Observer.
+package workspace
+
+import (
+	"context"
+	"errors"
+	"ripvcs/utils"
+	"time"
+)
+
+// DefaultWorkers Number of concurrent workers used when none is given
+const DefaultWorkers = utils.DefaultWorkers
+
+// Orders in which results are reported
+const (
+	// OrderPath Report results sorted by repository path
+	OrderPath = utils.OrderPath
+	// OrderCompletion Report results as soon as each repository finishes
+	OrderCompletion = utils.OrderCompletion
+)
+
+// Result Outcome of running an operation on a single repository
+type Result = utils.RepoResult
+
+// EventKind Type of event notified to an Observer
+type EventKind int
+
+const (
+	// RepoStarted An operation started on a repository
+	RepoStarted EventKind = iota
+	// RepoFinished An operation finished on a repository. Result is set
+	RepoFinished
+	// ManifestStarted Repositories from a .repos file are about to be imported
+	ManifestStarted
+	// ManifestExcluded A .repos file was excluded from a recursive import or lock
+	ManifestExcluded
+	// ReposQueued Repositories are about to be processed. Total is set
+	ReposQueued
+	// RepoProgress Git reported the progress of a transfer. Progress is set
+	RepoProgress
+)
+
+// Event Progress notification of a workspace operation
+type Event struct {
+	Kind      EventKind
+	Path      string
+	Operation string
+	Result    *Result
+	Progress  *utils.GitProgress
+	Total     int
+}
+
+// Observer Receives progress events. Notify can be called concurrently
+type Observer interface {
+	Notify(event Event)
+}
+
+// ObserverFunc Adapter to use a function as an Observer
+type ObserverFunc func(event Event)
+
+// Notify Call the wrapped function
+func (f ObserverFunc) Notify(event Event) {
+	f(event)
+}
+
+// Workspace Collection of repositories found relative to a root path
+type Workspace struct {
+	Root     string
+	Workers  int
+	Observer Observer
+	// RepoTimeout Maximum duration of an operation on a single repository. Zero means no limit
+	RepoTimeout time.Duration
+	// Order Order in which results are returned and notified. Either OrderPath or OrderCompletion
+	Order string
+	// ReportProgress Notify the progress of git transfers through RepoProgress events
+	ReportProgress bool
+	// Filter Selection of the repositories and .repos file entries operations run on. Nil selects all
+	Filter *Filter
+	// Discovery Settings used to search the repositories found relative to the root
+	Discovery utils.DiscoveryOptions
+}
+
+// New Create a workspace rooted at the given path
+func New(root string) *Workspace {
+	return &Workspace{Root: root, Workers: DefaultWorkers, Order: OrderPath}
+}
+
+// Repositories Get the paths of all the repositories of any supported type found in the workspace
+func (w *Workspace) Repositories() ([]string, error) {
+	return utils.WalkRepositories(w.Root, w.Discovery, utils.IsRepository)
+}
+
+// Discover Get the repositories of any supported type found in the workspace along with the
+// bare repositories left out
+func (w *Workspace) Discover() (utils.Discovery, error) {
+	return utils.DiscoverRepositories(w.Root, w.Discovery, utils.IsRepository)
+}
+
+// IgnoredRepositories Get the repositories of any supported type left out of the workspace because
+// they are ignored by the discovery patterns or by .rvignore files
+func (w *Workspace) IgnoredRepositories() ([]string, error) {
+	return utils.WalkIgnoredRepositories(w.Root, w.Discovery, utils.IsRepository)
+}
+
+// GitRepositories Get the git repositories found relative to the root of the workspace
+func (w *Workspace) GitRepositories() ([]string, error) {
+	return utils.WalkRepositories(w.Root, w.Discovery, utils.IsGitRepository)
+}
+
+// notify Send an event to the observer, if any
+func (w *Workspace) notify(event Event) {
+	if w.Observer != nil {
+		w.Observer.Notify(event)
+	}
+}
+
+// forEach Run the given operation concurrently on each path and collect its results
+// following the order of the workspace. Operations returning nil are not reported.
+// Once the context is done, the remaining paths are reported as not finished.
+func (w *Workspace) forEach(ctx context.Context, paths []string, operation string, run utils.RepoFunc) []Result {
+	executor := utils.Executor{
+		Workers:     w.Workers,
+		Order:       w.Order,
+		RepoTimeout: w.RepoTimeout,
+		OnStart: func(path string) {
+			w.notify(Event{Kind: RepoStarted, Path: path, Operation: operation})
+		},
+		OnResult: func(result Result) {
+			w.notify(Event{Kind: RepoFinished, Path: result.Path, Operation: operation, Result: &result})
+		},
+	}
+	if w.ReportProgress {
+		executor.OnProgress = func(path string, progress utils.GitProgress) {
+			w.notify(Event{Kind: RepoProgress, Path: path, Operation: operation, Progress: &progress})
+		}
+	}
+	w.notify(Event{Kind: ReposQueued, Operation: operation, Total: len(paths)})
+	return executor.Run(ctx, paths, operation, run)
+}
+
+// Interrupted Check if an operation did not finish because it was cancelled or timed out
+func Interrupted(result Result) bool {
+	return errors.Is(result.Err, context.Canceled) || errors.Is(result.Err, context.DeadlineExceeded)
+}
+
+// Failed Get the results that were not successful
+func Failed(results []Result) []Result {
+	var failed []Result
+	for _, result := range results {
+		if !result.Success {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,167 @@
+package test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"ripvcs/pkg/workspace"
+	"ripvcs/utils"
+	"strings"
+	"testing"
+)
+
+// writeTestArchives Write a tar.gz and a zip archive with the given files and get the tar.gz checksum
+func writeTestArchives(t *testing.T, dir string, files map[string]string) string {
+	t.Helper()
+	tarFile, err := os.Create(filepath.Join(dir, "pkg.tar.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gzipWriter := gzip.NewWriter(tarFile)
+	tarWriter := tar.NewWriter(gzipWriter)
+	zipFile, err := os.Create(filepath.Join(dir, "pkg.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zipWriter := zip.NewWriter(zipFile)
+	for name, content := range files {
+		if err := tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tarWriter.Write([]byte(content))
+		writer, err := zipWriter.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer.Write([]byte(content))
+	}
+	tarWriter.Close()
+	gzipWriter.Close()
+	tarFile.Close()
+	zipWriter.Close()
+	zipFile.Close()
+
+	content, err := os.ReadFile(filepath.Join(dir, "pkg.tar.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checksum := sha256.Sum256(content)
+	return hex.EncodeToString(checksum[:])
+}
+
+func TestArchiveRepositories(t *testing.T) {
+	dir := t.TempDir()
+	checksum := writeTestArchives(t, dir, map[string]string{"pkg-1.0/src/main.c": "int main() {}\n", "pkg-1.0/README.md": "pkg\n"})
+	reposFile := writeReposFile(t, filepath.Join(dir, "deps.repos"), `repositories:
+  tarpkg:
+    type: tar
+    url: `+filepath.Join(dir, "pkg.tar.gz")+`
+    sha256: `+checksum+`
+    strip-components: 1
+  zippkg:
+    type: zip
+    url: file://`+filepath.Join(dir, "pkg.zip")+`
+`)
+	config, err := utils.ParseReposFile(reposFile)
+	if err != nil || config.Repositories["tarpkg"].StripComponents != 1 {
+		t.Fatalf("Expected to parse the archive repositories. Got %v, error %v", config, err)
+	}
+
+	ws := workspace.New(filepath.Join(dir, "ws"))
+	if results := ws.Validate(context.Background(), config, workspace.ValidateOptions{}); len(workspace.Failed(results)) != 0 {
+		t.Errorf("Expected the archives to be valid. Got %v", results)
+	}
+	results, err := ws.Import(context.Background(), workspace.ImportOptions{Input: reposFile})
+	if err != nil {
+		t.Fatalf("Expected to extract the archives. Got %v, error %v", results, err)
+	}
+	for _, path := range []string{"tarpkg/src/main.c", "tarpkg/README.md", "zippkg/pkg-1.0/src/main.c"} {
+		if _, err := os.Stat(filepath.Join(dir, "ws", path)); err != nil {
+			t.Errorf("Expected %s to be extracted", path)
+		}
+	}
+
+	results, err = ws.Import(context.Background(), workspace.ImportOptions{Input: reposFile})
+	if err != nil || len(results) != 2 || !results[0].Skipped || !results[1].Skipped {
+		t.Errorf("Expected unchanged archives to be skipped. Got %v, error %v", results, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "ws", "tarpkg", "src", "main.c"), []byte("changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results, err = ws.Import(context.Background(), workspace.ImportOptions{Input: reposFile})
+	if err == nil || results[0].Err == nil || !strings.Contains(results[0].Err.Error(), "modified: src/main.c") {
+		t.Errorf("Expected the modified archive to be reported. Got %v", results)
+	}
+
+	results, err = ws.Import(context.Background(), workspace.ImportOptions{Input: reposFile, OverwriteExisting: true})
+	if err != nil || len(workspace.Failed(results)) != 0 {
+		t.Errorf("Expected to overwrite the modified archive. Got %v, error %v", results, err)
+	}
+
+	badFile := writeReposFile(t, filepath.Join(dir, "bad.repos"), `repositories:
+  badpkg:
+    type: tar
+    url: `+filepath.Join(dir, "pkg.tar.gz")+`
+    sha256: `+strings.Repeat("0", 64)+`
+`)
+	results, err = ws.Import(context.Background(), workspace.ImportOptions{Input: badFile})
+	if err == nil || len(results) != 1 || !strings.Contains(results[0].Err.Error(), "checksum mismatch") {
+		t.Errorf("Expected the checksum mismatch to be reported. Got %v", results)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ws", "badpkg")); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing to be extracted on checksum mismatch")
+	}
+}
+
+func TestExtractArchiveChainedSymlinks(t *testing.T) {
+	archives := map[string][]tar.Header{
+		// c is resolved through the link a/b, which points to the extraction directory itself
+		"chained": {
+			{Name: "a/", Typeflag: tar.TypeDir, Mode: 0755},
+			{Name: "a/b", Typeflag: tar.TypeSymlink, Linkname: ".."},
+			{Name: "c", Typeflag: tar.TypeSymlink, Linkname: "a/b/.."},
+			{Name: "c/evil", Typeflag: tar.TypeReg, Mode: 0644},
+		},
+		// c is valid when extracted and only points outside once a/x is extracted
+		"redirected": {
+			{Name: "a/", Typeflag: tar.TypeDir, Mode: 0755},
+			{Name: "c", Typeflag: tar.TypeSymlink, Linkname: "a/x/../.."},
+			{Name: "a/x", Typeflag: tar.TypeSymlink, Linkname: ".."},
+			{Name: "c/evil", Typeflag: tar.TypeReg, Mode: 0644},
+		},
+	}
+	for name, headers := range archives {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			archivePath := filepath.Join(dir, "evil.tar")
+			file, err := os.Create(archivePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tarWriter := tar.NewWriter(file)
+			for _, header := range headers {
+				if err := tarWriter.WriteHeader(&header); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tarWriter.Close()
+			file.Close()
+
+			extractPath := filepath.Join(dir, "ws", "out")
+			if err := utils.ExtractArchive(archivePath, "tar", extractPath, 0); err == nil {
+				t.Errorf("Expected the archive escaping through links to be rejected")
+			}
+			for _, path := range []string{filepath.Join(dir, "evil"), filepath.Join(dir, "ws", "evil")} {
+				if _, err := os.Lstat(path); !os.IsNotExist(err) {
+					t.Errorf("Expected nothing to be written outside the extraction directory. Found %s", path)
+				}
+			}
+		})
+	}
+}
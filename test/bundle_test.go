@@ -0,0 +1,50 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"ripvcs/pkg/workspace"
+	"testing"
+)
+
+func TestWorkspaceBundle(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	headSha := runGit(t, filepath.Join(dir, "seed"), "rev-parse", "HEAD")
+	reposFile := writeReposFile(t, filepath.Join(dir, "deps.repos"), `repositories:
+  src/repo:
+    type: git
+    url: `+remotePath+`
+    version: main
+`)
+	bundlePath := filepath.Join(dir, "ws.rvbundle")
+
+	ws := workspace.New(dir)
+	results, err := ws.CreateBundle(context.Background(), workspace.BundleOptions{Input: reposFile, Output: bundlePath})
+	if err != nil || len(workspace.Failed(results)) != 0 {
+		t.Fatalf("Expected to create the bundle. Got %v, error %v", results, err)
+	}
+
+	// The remote is not needed to import the bundle
+	if err := os.Rename(remotePath, remotePath+".offline"); err != nil {
+		t.Fatal(err)
+	}
+	ws = workspace.New(filepath.Join(dir, "ws"))
+	results, err = ws.Import(context.Background(), workspace.ImportOptions{FromBundle: bundlePath})
+	if err != nil || len(results) != 1 || !results[0].Success {
+		t.Fatalf("Expected to import the bundle. Got %v, error %v", results, err)
+	}
+	repoPath := filepath.Join(dir, "ws", "src", "repo")
+	if sha := runGit(t, repoPath, "rev-parse", "HEAD"); sha != headSha {
+		t.Errorf("Expected the bundled commit %s to be checked out. Got %s", headSha, sha)
+	}
+	if url := runGit(t, repoPath, "remote", "get-url", "origin"); url != remotePath {
+		t.Errorf("Expected origin to be %s. Got %s", remotePath, url)
+	}
+
+	results, err = ws.Import(context.Background(), workspace.ImportOptions{FromBundle: bundlePath})
+	if err != nil || len(results) != 1 || !results[0].Skipped {
+		t.Errorf("Expected the existing repository to be skipped. Got %v, error %v", results, err)
+	}
+}
@@ -0,0 +1,98 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"ripvcs/utils"
+	"testing"
+	"time"
+)
+
+func TestCloneWithCache(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	cacheDir := filepath.Join(dir, "cache")
+
+	for _, name := range []string{"first", "second"} {
+		clonePath := filepath.Join(dir, name)
+		status, err := utils.CloneGitRepo(context.Background(), utils.CloneOptions{URL: remotePath, Version: "main", Path: clonePath, CacheDir: cacheDir})
+		if status != utils.SuccessfullClone {
+			t.Fatalf("Expected to clone %s using the cache. Error %v", name, err)
+		}
+		// Dissociated clones must not depend on the cache
+		if _, err := os.Stat(filepath.Join(clonePath, ".git", "objects", "info", "alternates")); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be dissociated from the cache", name)
+		}
+	}
+
+	entries, err := utils.ListCacheEntries(context.Background(), cacheDir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected a single cached repository. Got %v, error %v", entries, err)
+	}
+	if entries[0].URL != remotePath || entries[0].Path != utils.CacheMirrorPath(cacheDir, remotePath) {
+		t.Errorf("Unexpected cache entry %v", entries[0])
+	}
+	if _, err := utils.VerifyCacheMirror(context.Background(), entries[0].Path); err != nil {
+		t.Errorf("Expected the cached repository to be valid. Error %v", err)
+	}
+
+	removed, err := utils.PruneCache(context.Background(), cacheDir, time.Now().Add(-time.Hour))
+	if err != nil || len(removed) != 0 {
+		t.Errorf("Expected recently used repositories to be kept. Removed %v, error %v", removed, err)
+	}
+	removed, err = utils.PruneCache(context.Background(), cacheDir, time.Now().Add(time.Hour))
+	if err != nil || len(removed) != 1 {
+		t.Errorf("Expected unused repositories to be removed. Removed %v, error %v", removed, err)
+	}
+}
+
+func TestUpdateCacheMirror(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	cacheDir := filepath.Join(dir, "cache")
+	workPath := filepath.Join(dir, "work")
+	runGit(t, dir, "clone", remotePath, workPath)
+	runGit(t, workPath, "tag", "v1.0")
+	runGit(t, workPath, "push", "origin", "v1.0")
+	sha := runGit(t, workPath, "rev-parse", "HEAD")
+
+	mirrorPath, err := utils.UpdateCacheMirror(context.Background(), cacheDir, remotePath, "main", false)
+	if err != nil {
+		t.Fatalf("Expected to create the mirror. Error %v", err)
+	}
+	runGit(t, workPath, "commit", "--allow-empty", "-m", "New commit")
+	runGit(t, workPath, "push", "origin", "HEAD")
+	newSha := runGit(t, workPath, "rev-parse", "HEAD")
+
+	// Versions already in the mirror do not need a fetch
+	for _, version := range []string{sha, "v1.0"} {
+		if _, err := utils.UpdateCacheMirror(context.Background(), cacheDir, remotePath, version, false); err != nil {
+			t.Fatalf("Expected to use the mirror for %s. Error %v", version, err)
+		}
+		if head := runGit(t, mirrorPath, "rev-parse", "main"); head != sha {
+			t.Errorf("Expected the mirror not to be fetched for %s. Got main at %s", version, head)
+		}
+	}
+	if _, err := utils.UpdateCacheMirror(context.Background(), cacheDir, remotePath, "main", false); err != nil {
+		t.Fatalf("Expected to update the mirror. Error %v", err)
+	}
+	if head := runGit(t, mirrorPath, "rev-parse", "main"); head != newSha {
+		t.Errorf("Expected the mirror to be fetched for a branch. Got main at %s", head)
+	}
+
+	// A mirror used by a clone can not be updated until the clone is done
+	unlock, err := utils.LockCacheMirror(context.Background(), mirrorPath, false)
+	if err != nil {
+		t.Fatalf("Expected to lock the mirror. Error %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if _, err := utils.UpdateCacheMirror(ctx, cacheDir, remotePath, "main", false); err == nil {
+		t.Errorf("Expected the mirror update to wait for the clone")
+	}
+	unlock()
+	if _, err := utils.UpdateCacheMirror(context.Background(), cacheDir, remotePath, "main", false); err != nil {
+		t.Errorf("Expected to update the mirror once unlocked. Error %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package test
+
+import (
+	"context"
+	"path/filepath"
+	"ripvcs/pkg/workspace"
+	"testing"
+)
+
+func TestWorkspaceCheck(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	root := filepath.Join(dir, "ws")
+	for _, name := range []string{"matching", "branch", "url", "tagged", "stray"} {
+		runGit(t, dir, "clone", remotePath, filepath.Join(root, name))
+	}
+	runGit(t, filepath.Join(root, "branch"), "switch", "-c", "feature")
+	runGit(t, filepath.Join(root, "url"), "remote", "set-url", "origin", "https://example.com/other.git")
+	runGit(t, filepath.Join(root, "tagged"), "tag", "v1.0")
+	sha := runGit(t, filepath.Join(root, "matching"), "rev-parse", "HEAD")
+
+	reposFile := writeReposFile(t, filepath.Join(dir, "ws.repos"), `repositories:
+  matching:
+    type: git
+    url: `+remotePath+`
+    version: `+sha[:10]+`
+  branch:
+    type: git
+    url: `+remotePath+`
+    version: main
+  url:
+    type: git
+    url: `+remotePath+`
+    version: main
+  tagged:
+    type: git
+    url: `+remotePath+`
+    version: v1.0
+  missing:
+    type: git
+    url: `+remotePath+`
+`)
+
+	ws := workspace.New(root)
+	entries, err := ws.Check(context.Background(), workspace.ManifestOptions{Input: reposFile})
+	if err != nil {
+		t.Fatalf("Expected to check workspace. Error %v", err)
+	}
+	expected := map[string]string{
+		"branch":   workspace.CheckDrifted,
+		"matching": workspace.CheckMatching,
+		"missing":  workspace.CheckMissing,
+		"stray":    workspace.CheckExtra,
+		"tagged":   workspace.CheckMatching,
+		"url":      workspace.CheckDrifted,
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %v", len(expected), entries)
+	}
+	for _, entry := range entries {
+		name := filepath.Base(entry.Path)
+		if entry.State != expected[name] {
+			t.Errorf("Expected %s to be %s, got %s %v", name, expected[name], entry.State, entry.Details)
+		}
+		if result := entry.Result(); result.Success != (entry.State == workspace.CheckMatching) {
+			t.Errorf("Expected result of %s to succeed only when matching", name)
+		}
+	}
+}
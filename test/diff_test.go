@@ -0,0 +1,113 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"ripvcs/pkg/workspace"
+	"ripvcs/utils"
+	"testing"
+)
+
+func TestSplitCombinedPatch(t *testing.T) {
+	patch := `diff --git a/src/first/README.md b/src/first/README.md
+--- a/src/first/README.md
++++ b/src/first/README.md
+diff --git a/src/first/nested/file b/src/first/nested/file
+--- a/src/first/nested/file
++++ b/src/first/nested/file
+diff --git a/src/second/README.md b/src/second/README.md
+--- a/src/second/README.md
++++ b/src/second/README.md
+`
+	patches, err := utils.SplitCombinedPatch(patch, []string{"src/first", "src/first/nested", "src/second"})
+	if err != nil {
+		t.Fatalf("Expected to split patch. Error %v", err)
+	}
+	if len(patches) != 3 {
+		t.Fatalf("Expected patches of 3 repositories, got %v", patches)
+	}
+	if utils.PatchFiles(patches["src/first/nested"]) != 1 || utils.PatchFiles(patches["src/first"]) != 1 {
+		t.Errorf("Expected nested files to belong to the nested repository, got %v", patches)
+	}
+	if utils.PatchStripComponents("src/first/nested") != 4 || utils.PatchStripComponents(".") != 1 {
+		t.Errorf("Unexpected number of path components to strip")
+	}
+
+	if _, err := utils.SplitCombinedPatch(patch, []string{"src/first"}); err == nil {
+		t.Errorf("Expected an error for files outside of any repository")
+	}
+}
+
+func TestWorkspaceDiffApply(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	for _, root := range []string{"ws", "other"} {
+		for _, name := range []string{"src/first", "src/second"} {
+			runGit(t, dir, "clone", remotePath, filepath.Join(dir, root, name))
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ws", "src", "first", "README.md"), []byte("changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ws", "src", "first", "NEW.md"), []byte("new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ws := workspace.New(filepath.Join(dir, "ws"))
+	repos, err := ws.Repositories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := ws.Diff(context.Background(), repos, workspace.DiffOptions{Patch: true})
+	if err != nil {
+		t.Fatalf("Expected to get diff. Error %v", err)
+	}
+	if len(results) != 1 || results[0].Path != filepath.Join(dir, "ws", "src", "first") {
+		t.Fatalf("Expected only the changed repository to be reported, got %v", results)
+	}
+
+	patchPath := filepath.Join(dir, "changes.patch")
+	if err := os.WriteFile(patchPath, []byte(workspace.CombinedPatch(results)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	other := workspace.New(filepath.Join(dir, "other"))
+	results, err = other.Apply(context.Background(), workspace.ApplyOptions{Patch: patchPath})
+	if err != nil {
+		t.Fatalf("Expected to apply patch. Error %v", err)
+	}
+	if len(workspace.Failed(results)) > 0 || len(results) != 1 {
+		t.Fatalf("Expected patch to be applied to a single repository, got %v", results)
+	}
+	content, _ := os.ReadFile(filepath.Join(dir, "other", "src", "first", "README.md"))
+	if string(content) != "changed\n" {
+		t.Errorf("Expected the change to be applied, got %q", content)
+	}
+	content, _ = os.ReadFile(filepath.Join(dir, "other", "src", "first", "NEW.md"))
+	if string(content) != "new\n" {
+		t.Errorf("Expected the untracked file to be created, got %q", content)
+	}
+	if status := runGit(t, filepath.Join(dir, "ws", "src", "first"), "status", "--porcelain", "NEW.md"); status != "?? NEW.md" {
+		t.Errorf("Expected the untracked file to stay untracked, got %q", status)
+	}
+
+	// The first repository already has the changes, so the second one must not be changed either
+	if err := os.WriteFile(filepath.Join(dir, "ws", "src", "second", "README.md"), []byte("second\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results, _ = ws.Diff(context.Background(), repos, workspace.DiffOptions{Patch: true})
+	if err := os.WriteFile(patchPath, []byte(workspace.CombinedPatch(results)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results, err = other.Apply(context.Background(), workspace.ApplyOptions{Patch: patchPath})
+	if err == nil || len(results) != 2 || results[0].Success || !results[1].Skipped {
+		t.Fatalf("Expected the patch to be rejected as a whole, got %v, error %v", results, err)
+	}
+	if status := runGit(t, filepath.Join(dir, "other", "src", "second"), "status", "--porcelain"); status != "" {
+		t.Errorf("Expected the second repository to be left unchanged, got %q", status)
+	}
+
+	if _, err := ws.Diff(context.Background(), repos, workspace.DiffOptions{Patch: true, Stat: true}); err == nil {
+		t.Errorf("Expected an error when combining a patch with stat")
+	}
+}
@@ -0,0 +1,64 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"ripvcs/utils"
+	"slices"
+	"testing"
+)
+
+func TestWalkRepositories(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	root := filepath.Join(dir, "ws")
+	for _, name := range []string{"src/a", "src/a-b", "src/a/nested", "build/fetched", "deep/er/repo"} {
+		runGit(t, dir, "clone", remotePath, filepath.Join(root, name))
+	}
+	runGit(t, filepath.Join(root, "src", "a"), "worktree", "add", filepath.Join(root, "worktree"))
+	runGit(t, dir, "clone", "--bare", remotePath, filepath.Join(root, "mirror.git"))
+	runGit(t, dir, "clone", remotePath, filepath.Join(dir, "outside", "linked"))
+	if err := os.Symlink(filepath.Join(dir, "outside"), filepath.Join(root, "outside")); err != nil {
+		t.Fatal(err)
+	}
+	// A link to an ancestor must not be searched forever
+	if err := os.Symlink(root, filepath.Join(root, "src", "loop")); err != nil {
+		t.Fatal(err)
+	}
+
+	rel := func(repos []string) []string {
+		var names []string
+		for _, repo := range repos {
+			name, _ := filepath.Rel(root, repo)
+			names = append(names, filepath.ToSlash(name))
+		}
+		return names
+	}
+	cases := []struct {
+		opts     utils.DiscoveryOptions
+		expected []string
+	}{
+		{utils.DiscoveryOptions{}, []string{"deep/er/repo", "src/a", "src/a/nested", "src/a-b", "worktree"}},
+		{utils.DiscoveryOptions{MaxDepth: 2}, []string{"src/a", "src/a-b", "worktree"}},
+		{utils.DiscoveryOptions{FollowSymlinks: true}, []string{"deep/er/repo", "outside/linked", "src/a", "src/a/nested", "src/a-b", "worktree"}},
+	}
+	for _, c := range cases {
+		repos, err := utils.WalkRepositories(root, c.opts, utils.IsGitRepository)
+		if err != nil {
+			t.Fatalf("Failed to search repositories with %+v. Error %v", c.opts, err)
+		}
+		if names := rel(repos); !slices.Equal(names, c.expected) {
+			t.Errorf("Expected %v with %+v. Got %v", c.expected, c.opts, names)
+		}
+	}
+
+	discovery, err := utils.DiscoverRepositories(root, utils.DiscoveryOptions{}, utils.IsGitRepository)
+	if err != nil || !slices.Equal(rel(discovery.Bare), []string{"mirror.git"}) {
+		t.Errorf("Expected the bare repository to be reported. Got %v, error %v", discovery.Bare, err)
+	}
+
+	// Heavy directories are still searched when given as root
+	if repos, err := utils.WalkRepositories(filepath.Join(root, "build"), utils.DiscoveryOptions{}, utils.IsGitRepository); err != nil || len(repos) != 1 {
+		t.Errorf("Expected to find the repository of the build directory. Got %v, error %v", repos, err)
+	}
+}
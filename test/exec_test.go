@@ -0,0 +1,69 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"ripvcs/pkg/workspace"
+	"strings"
+	"testing"
+)
+
+func TestWorkspaceExec(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"first", "second"} {
+		runGit(t, dir, "init", "--initial-branch=main", filepath.Join(dir, name))
+	}
+	if err := os.WriteFile(filepath.Join(dir, "second", "marker"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ws := workspace.New(dir)
+	repos, err := ws.Repositories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logDir := filepath.Join(t.TempDir(), "logs")
+	results, summary, err := ws.Exec(context.Background(), repos, workspace.ExecOptions{
+		Command: []string{"echo out; echo err >&2; test -f marker"},
+		LogDir:  logDir,
+	})
+	if err != nil {
+		t.Fatalf("Expected to run command. Error %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %v", results)
+	}
+	first := results[0]
+	if first.Success || first.ExitCode == nil || *first.ExitCode != 1 || first.Output != "out\n" || first.Stderr != "err\n" {
+		t.Errorf("Unexpected result of failing command %+v", first)
+	}
+	if !results[1].Success || *results[1].ExitCode != 0 {
+		t.Errorf("Unexpected result of successful command %+v", results[1])
+	}
+	if len(summary.ExitCodes[0]) != 1 || len(summary.ExitCodes[1]) != 1 {
+		t.Errorf("Unexpected exit code summary %v", summary.ExitCodes)
+	}
+	content, err := os.ReadFile(filepath.Join(logDir, "first.log"))
+	if err != nil || !strings.Contains(string(content), "out\n") || !strings.HasSuffix(string(content), "Exit code: 1\n") {
+		t.Errorf("Expected log of first repository, got %q (%v)", content, err)
+	}
+
+	ws.Workers = 1
+	results, summary, err = ws.Exec(context.Background(), repos, workspace.ExecOptions{
+		Command:      []string{"rev-parse", "--verify", "does-not-exist"},
+		Git:          true,
+		FailFast:     true,
+		OnlyFailures: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected to run command. Error %v", err)
+	}
+	if len(results) != 1 || results[0].Success || len(summary.Skipped) != 1 {
+		t.Errorf("Expected only the first failure to be reported, got %v and skipped %v", results, summary.Skipped)
+	}
+
+	if _, _, err := ws.Exec(context.Background(), repos, workspace.ExecOptions{}); err == nil {
+		t.Errorf("Expected an error without a command")
+	}
+}
@@ -0,0 +1,92 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"ripvcs/utils"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestExecutorPathOrder(t *testing.T) {
+	paths := []string{"c", "a", "d", "b"}
+	delays := map[string]time.Duration{"a": 30 * time.Millisecond, "b": 0, "c": 10 * time.Millisecond, "d": 0}
+
+	var reported []string
+	executor := utils.Executor{
+		Workers: 4,
+		Order:   utils.OrderPath,
+		OnResult: func(result utils.RepoResult) {
+			reported = append(reported, result.Path)
+		},
+	}
+	results := executor.Run(context.Background(), paths, "test", func(ctx context.Context, path string) *utils.RepoResult {
+		time.Sleep(delays[path])
+		if path == "d" {
+			return nil
+		}
+		return &utils.RepoResult{Path: path, Success: true}
+	})
+
+	expected := []string{"a", "b", "c"}
+	if !slices.Equal(reported, expected) {
+		t.Errorf("Expected results to be reported in path order %v. Got %v", expected, reported)
+	}
+	if len(results) != len(expected) {
+		t.Errorf("Expected %d results. Got %d", len(expected), len(results))
+	}
+}
+
+func TestExecutorCompletionOrder(t *testing.T) {
+	paths := []string{"slow", "fast"}
+	var reported []string
+	executor := utils.Executor{
+		Workers: 2,
+		Order:   utils.OrderCompletion,
+		OnResult: func(result utils.RepoResult) {
+			reported = append(reported, result.Path)
+		},
+	}
+	executor.Run(context.Background(), paths, "test", func(ctx context.Context, path string) *utils.RepoResult {
+		if path == "slow" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		return &utils.RepoResult{Path: path, Success: true}
+	})
+	if !slices.Equal(reported, []string{"fast", "slow"}) {
+		t.Errorf("Expected results to be reported in completion order. Got %v", reported)
+	}
+}
+
+func TestExecutorCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	executor := utils.Executor{Workers: 2}
+	results := executor.Run(ctx, []string{"a", "b"}, "test", func(ctx context.Context, path string) *utils.RepoResult {
+		t.Errorf("Expected operation to not run on a cancelled context")
+		return nil
+	})
+	if len(results) != 2 {
+		t.Fatalf("Expected cancelled repositories to be reported. Got %v", results)
+	}
+	for _, result := range results {
+		if !errors.Is(result.Err, context.Canceled) || result.Operation != "test" {
+			t.Errorf("Expected %s to be reported as cancelled. Got %v", result.Path, result.Err)
+		}
+	}
+
+	executor = utils.Executor{RepoTimeout: 10 * time.Millisecond}
+	results = executor.Run(context.Background(), []string{"a"}, "test", func(ctx context.Context, path string) *utils.RepoResult {
+		<-ctx.Done()
+		return &utils.RepoResult{Path: path, Err: ctx.Err()}
+	})
+	if len(results) != 1 || !errors.Is(results[0].Err, context.DeadlineExceeded) {
+		t.Errorf("Expected repository to time out. Got %v", results)
+	}
+
+	if utils.ValidateOrder("random") == nil {
+		t.Errorf("Expected to report invalid order")
+	}
+}
@@ -0,0 +1,157 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"ripvcs/pkg/workspace"
+	"ripvcs/utils"
+	"slices"
+	"testing"
+)
+
+func TestWorkspaceSelect(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	root := filepath.Join(dir, "ws")
+	for _, name := range []string{"src/first", "src/second", "tools/third"} {
+		runGit(t, dir, "clone", remotePath, filepath.Join(root, name))
+	}
+	runGit(t, filepath.Join(root, "tools", "third"), "switch", "-c", "feature")
+	runGit(t, filepath.Join(root, "src", "second"), "remote", "set-url", "origin", "https://github.com/our-org/second.git")
+	if err := os.WriteFile(filepath.Join(root, "src", "first", "README.md"), []byte("changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ws := workspace.New(root)
+	repos, err := ws.Repositories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dirty, clean := true, false
+	tests := []struct {
+		name     string
+		filter   workspace.Filter
+		expected []string
+	}{
+		{"empty", workspace.Filter{}, []string{"src/first", "src/second", "tools/third"}},
+		{"only parent directory", workspace.Filter{Only: []string{"src"}}, []string{"src/first", "src/second"}},
+		{"only name glob", workspace.Filter{Only: []string{"*ir*"}}, []string{"src/first", "tools/third"}},
+		{"skip", workspace.Filter{Skip: []string{"src/f*"}}, []string{"src/second", "tools/third"}},
+		{"url", workspace.Filter{URLMatch: regexp.MustCompile(`github\.com/our-org`)}, []string{"src/second"}},
+		{"branch", workspace.Filter{Branch: "feature"}, []string{"tools/third"}},
+		{"dirty", workspace.Filter{Dirty: &dirty}, []string{"src/first"}},
+		{"clean", workspace.Filter{Dirty: &clean, Skip: []string{"third"}}, []string{"src/second"}},
+		{"changed since", workspace.Filter{ChangedSince: "HEAD"}, []string{"src/first"}},
+		{"missing ref", workspace.Filter{ChangedSince: "does-not-exist"}, nil},
+	}
+	for _, test := range tests {
+		ws.Filter = &test.filter
+		var selected []string
+		for _, repoPath := range ws.Select(context.Background(), repos) {
+			relPath, _ := filepath.Rel(root, repoPath)
+			selected = append(selected, filepath.ToSlash(relPath))
+		}
+		if !slices.Equal(selected, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, selected)
+		}
+	}
+}
+
+func TestWorkspaceImportFilter(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	reposFile := writeReposFile(t, filepath.Join(dir, "deps.repos"), `repositories:
+  src/first:
+    type: git
+    url: `+remotePath+`
+    version: main
+  src/second:
+    type: git
+    url: `+remotePath+`
+    version: other
+`)
+
+	ws := workspace.New(filepath.Join(dir, "ws"))
+	ws.Filter = &workspace.Filter{Branch: "main"}
+	results, err := ws.Import(context.Background(), workspace.ImportOptions{Input: reposFile, Retries: 1})
+	if err != nil {
+		t.Fatalf("Expected to import repositories. Error %v", err)
+	}
+	if len(results) != 1 || results[0].Path != filepath.Join(dir, "ws", "src", "first") {
+		t.Errorf("Expected only the entry with the selected version to be imported, got %v", results)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ws", "src", "second")); err == nil {
+		t.Errorf("Expected the other entry not to be imported")
+	}
+}
+
+func TestWorkspaceGroups(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	reposFile := writeReposFile(t, filepath.Join(dir, "deps.repos"), `repositories:
+  perception:
+    type: git
+    url: `+remotePath+`
+    version: main
+    tags: [perception]
+  simulation:
+    type: git
+    url: `+remotePath+`
+    version: main
+    tags: [perception, simulation]
+  tools:
+    type: git
+    url: `+remotePath+`
+    version: main
+`)
+
+	ws := workspace.New(filepath.Join(dir, "ws"))
+	ws.Filter = &workspace.Filter{Groups: []string{"perception"}, SkipGroups: []string{"simulation"}}
+	results, err := ws.Import(context.Background(), workspace.ImportOptions{Input: reposFile, Retries: 1})
+	if err != nil {
+		t.Fatalf("Expected to import repositories. Error %v", err)
+	}
+	if len(results) != 1 || filepath.Base(results[0].Path) != "perception" {
+		t.Fatalf("Expected only the perception group to be imported, got %v", results)
+	}
+
+	ws.Filter = nil
+	if _, err := ws.Import(context.Background(), workspace.ImportOptions{Input: reposFile, Retries: 1}); err != nil {
+		t.Fatalf("Expected to import repositories. Error %v", err)
+	}
+	repos, err := ws.Repositories()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.Filter = &workspace.Filter{Groups: []string{"simulation"}}
+	if selected := ws.Select(context.Background(), repos); len(selected) != 1 || filepath.Base(selected[0]) != "simulation" {
+		t.Errorf("Expected the imported tags to select the simulation repository, got %v", selected)
+	}
+
+	ws.Filter = nil
+	config, _ := ws.Export(context.Background(), repos, workspace.ExportOptions{})
+	if tags := config.Repositories["simulation"].Tags; !slices.Equal(tags, []string{"perception", "simulation"}) {
+		t.Errorf("Expected tags to be exported, got %v", tags)
+	}
+	if tags := config.Repositories["tools"].Tags; len(tags) != 0 {
+		t.Errorf("Expected no tags for untagged repositories, got %v", tags)
+	}
+
+	// Tags of other version control systems are recorded in their metadata directory
+	hgPath := filepath.Join(dir, "ws", "hg_repo")
+	if err := os.MkdirAll(filepath.Join(hgPath, ".hg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.SetRepositoryTags(context.Background(), hgPath, []string{"simulation"}); err != nil {
+		t.Fatalf("Expected to record the tags of a Mercurial repository. Error %v", err)
+	}
+	if tags, err := utils.RepositoryTags(context.Background(), hgPath); err != nil || !slices.Equal(tags, []string{"simulation"}) {
+		t.Errorf("Expected to read the tags of a Mercurial repository, got %v, error %v", tags, err)
+	}
+	ws.Filter = &workspace.Filter{Groups: []string{"simulation"}}
+	if selected := ws.Select(context.Background(), []string{hgPath, repos[0]}); !slices.Equal(selected, []string{hgPath}) {
+		t.Errorf("Expected the tags to select the Mercurial repository, got %v", selected)
+	}
+}
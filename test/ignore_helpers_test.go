@@ -0,0 +1,91 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"ripvcs/utils"
+	"slices"
+	"testing"
+)
+
+func TestIgnorePatterns(t *testing.T) {
+	cases := []struct {
+		patterns []string
+		path     string
+		isDir    bool
+		ignored  bool
+	}{
+		{[]string{"vendor"}, "src/vendor", true, true},
+		{[]string{"vendor"}, "vendor/lib", true, true},
+		{[]string{"/vendor"}, "src/vendor", true, false},
+		{[]string{"src/*_generated"}, "src/msgs_generated", true, true},
+		{[]string{"src/*_generated"}, "other/src/msgs_generated", true, false},
+		{[]string{"**/fixtures"}, "a/b/fixtures", true, true},
+		{[]string{"third_party/**"}, "third_party/x/y", true, true},
+		{[]string{"a/**/z"}, "a/z", true, true},
+		{[]string{"a/**/z"}, "a/b/c/z", true, true},
+		{[]string{"*.repos"}, "test/example.repos", false, true},
+		{[]string{"fixtures/"}, "fixtures", false, false},
+		{[]string{"repo?"}, "repo1", true, true},
+		{[]string{"repo[0-9]"}, "repoa", true, false},
+		{[]string{"repo[!0-9]"}, "repoa", true, true},
+		{[]string{"*", "!keep"}, "keep", true, false},
+		{[]string{"# comment", ""}, "# comment", true, false},
+		{[]string{`\#file`}, "#file", false, true},
+		{[]string{"vendor"}, "..hidden/vendor", true, true},
+	}
+	for _, c := range cases {
+		matcher, err := utils.NewIgnoreMatcher("/ws", c.patterns)
+		if err != nil {
+			t.Fatalf("Failed to parse %v. Error %v", c.patterns, err)
+		}
+		if ignored := (utils.IgnoreStack{matcher}).IsIgnored(filepath.Join("/ws", c.path), c.isDir); ignored != c.ignored {
+			t.Errorf("Expected %v to ignore %s: %v. Got %v", c.patterns, c.path, c.ignored, ignored)
+		}
+	}
+}
+
+func TestRvignoreFiles(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	root := filepath.Join(dir, "ws")
+	for _, name := range []string{"src/app", "src/vendored/lib", "src/generated", "tools"} {
+		runGit(t, dir, "clone", remotePath, filepath.Join(root, name))
+	}
+	for path, content := range map[string]string{
+		filepath.Join(root, utils.IgnoreFileName):             "vendored/\n",
+		filepath.Join(root, "src", utils.IgnoreFileName):      "generated\n",
+		filepath.Join(root, "src", "app", "deps.repos"):       "repositories: {}\n",
+		filepath.Join(root, "tools", "deps.repos"):            "repositories: {}\n",
+		filepath.Join(root, "tools", "test", "fixture.repos"): "repositories: {}\n",
+		filepath.Join(root, "tools", utils.IgnoreFileName):    "test/\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := utils.DiscoveryOptions{Ignore: []string{"tools"}}
+	repos, err := utils.WalkRepositories(root, opts, utils.IsGitRepository)
+	if expected := []string{filepath.Join(root, "src", "app")}; err != nil || !slices.Equal(repos, expected) {
+		t.Errorf("Expected to only find %v. Got %v, error %v", expected, repos, err)
+	}
+	ignored, err := utils.WalkIgnoredRepositories(root, opts, utils.IsGitRepository)
+	expected := []string{filepath.Join(root, "src", "generated"), filepath.Join(root, "src", "vendored", "lib"), filepath.Join(root, "tools")}
+	if err != nil || !slices.Equal(ignored, expected) {
+		t.Errorf("Expected to report %v as ignored. Got %v, error %v", expected, ignored, err)
+	}
+
+	reposFiles, err := utils.FindReposFiles(root, nil)
+	expected = []string{filepath.Join(root, "src", "app", "deps.repos"), filepath.Join(root, "tools", "deps.repos")}
+	if err != nil || !slices.Equal(reposFiles, expected) {
+		t.Errorf("Expected to find %v. Got %v, error %v", expected, reposFiles, err)
+	}
+	reposFiles, err = utils.FindReposFilesIgnoring(root, []string{filepath.Join(root, "tools")}, []string{"tools"})
+	if err != nil || len(reposFiles) != 0 {
+		t.Errorf("Expected the ignored cloned path not to be searched. Got %v, error %v", reposFiles, err)
+	}
+}
@@ -0,0 +1,209 @@
+package test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"ripvcs/pkg/workspace"
+	"ripvcs/utils"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestResolveGitVersion(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	seedPath := filepath.Join(dir, "seed")
+	runGit(t, seedPath, "tag", "-a", "v1.0", "-m", "Release")
+	runGit(t, seedPath, "push", "origin", "v1.0")
+	headSha := runGit(t, seedPath, "rev-parse", "HEAD")
+
+	for _, version := range []string{"", "main", "v1.0", headSha} {
+		sha, err := utils.ResolveGitVersion(context.Background(), remotePath, version, false)
+		if err != nil || sha != headSha {
+			t.Errorf("Expected version '%s' to resolve to %s. Got %s, error %v", version, headSha, sha, err)
+		}
+	}
+	if _, err := utils.ResolveGitVersion(context.Background(), remotePath, "missing", false); err == nil {
+		t.Errorf("Expected to fail resolving a missing version")
+	}
+}
+
+func TestWorkspaceLock(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	seedPath := filepath.Join(dir, "seed")
+	writeReposFile(t, filepath.Join(seedPath, "nested.repos"), `repositories:
+  nested:
+    type: git
+    url: `+remotePath+`
+    version: main
+`)
+	runGit(t, seedPath, "add", "nested.repos")
+	runGit(t, seedPath, "commit", "-m", "Add nested.repos")
+	runGit(t, seedPath, "push", "origin", "HEAD:main")
+	headSha := runGit(t, seedPath, "rev-parse", "HEAD")
+
+	reposFile := writeReposFile(t, filepath.Join(dir, "deps.repos"), `repositories:
+  repo:
+    type: git
+    url: `+remotePath+`
+    version: main
+`)
+	ws := workspace.New(dir)
+
+	config, results, err := ws.Lock(context.Background(), workspace.LockOptions{Input: reposFile})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Expected to lock one repository. Got %v, error %v", results, err)
+	}
+	if repo := config.Repositories["repo"]; repo.Version != headSha || repo.Ref != "main" || repo.Source != "" {
+		t.Errorf("Unexpected locked repository %v", repo)
+	}
+
+	config, _, err = ws.Lock(context.Background(), workspace.LockOptions{Input: reposFile, Recursive: true})
+	if err != nil || len(config.Repositories) != 2 {
+		t.Fatalf("Expected to lock the nested repository. Got %v, error %v", config, err)
+	}
+	if repo := config.Repositories["nested"]; repo.Version != headSha || repo.Source != "repo/nested.repos" {
+		t.Errorf("Unexpected nested locked repository %v", repo)
+	}
+
+	config, _, err = ws.Lock(context.Background(), workspace.LockOptions{Input: reposFile, Recursive: true, Exclude: []string{"nested.repos"}})
+	if err != nil || len(config.Repositories) != 1 {
+		t.Errorf("Expected the nested .repos file to be excluded. Got %v, error %v", config, err)
+	}
+
+	lockFile := reposFile + utils.LockFileSuffix
+	if err := os.WriteFile(lockFile, []byte("repositories: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := utils.IsReposFileValid(lockFile); err != nil {
+		t.Errorf("Expected lockfiles to be valid .repos files. Error %v", err)
+	}
+}
+
+func TestWorkspaceImportLocked(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	seedPath := filepath.Join(dir, "seed")
+	lockedSha := runGit(t, seedPath, "rev-parse", "HEAD")
+	reposFile := writeReposFile(t, filepath.Join(dir, "deps.repos"), `repositories:
+  repo:
+    type: git
+    url: `+remotePath+`
+    version: main
+`)
+	ws := workspace.New(filepath.Join(dir, "ws"))
+	if _, err := ws.Import(context.Background(), workspace.ImportOptions{Input: reposFile, Locked: true}); err == nil {
+		t.Errorf("Expected to fail without a lockfile")
+	}
+
+	writeReposFile(t, reposFile+utils.LockFileSuffix, `repositories:
+  repo:
+    type: git
+    url: `+remotePath+`
+    version: `+lockedSha+`
+    ref: main
+`)
+	// Move the branch after locking
+	runGit(t, seedPath, "commit", "--allow-empty", "-m", "Second commit")
+	runGit(t, seedPath, "push", "origin", "HEAD:main")
+
+	results, err := ws.Import(context.Background(), workspace.ImportOptions{Input: reposFile, Locked: true, Shallow: true})
+	if err != nil || len(workspace.Failed(results)) != 0 {
+		t.Fatalf("Expected to import the locked repositories. Got %v, error %v", results, err)
+	}
+	if sha := runGit(t, filepath.Join(dir, "ws", "repo"), "rev-parse", "HEAD"); sha != lockedSha {
+		t.Errorf("Expected the locked commit %s to be checked out. Got %s", lockedSha, sha)
+	}
+
+	config, _ := utils.ParseReposFile(reposFile)
+	locked, _ := utils.ParseReposFile(reposFile + utils.LockFileSuffix)
+	if drift := workspace.LockDrift(config, locked); len(drift) != 0 {
+		t.Errorf("Expected no drift. Got %v", drift)
+	}
+	config.Repositories["added"] = utils.Repository{Type: "git", URL: remotePath}
+	config.Repositories["repo"] = utils.Repository{Type: "git", URL: "https://example.com/repo.git", Version: "main"}
+	locked.Repositories["removed"] = utils.Repository{Type: "git", URL: remotePath}
+	expected := []string{"added: added", "url changed: repo (" + remotePath + " -> https://example.com/repo.git)", "removed: removed"}
+	if drift := workspace.LockDrift(config, locked); strings.Join(drift, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected drift %v. Got %v", expected, drift)
+	}
+}
+
+func TestWorkspaceLockMixed(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	headSha := runGit(t, filepath.Join(dir, "seed"), "rev-parse", "HEAD")
+	checksum := writeTestArchives(t, dir, map[string]string{"pkg-1.0/README.md": "pkg\n"})
+	manifest := `repositories:
+  repo:
+    type: git
+    url: ` + remotePath + `
+    version: main
+  tarpkg:
+    type: tar
+    url: ` + filepath.Join(dir, "pkg.tar.gz") + `
+    sha256: ` + checksum + `
+    strip-components: 1
+`
+	svnURL := ""
+	if _, err := exec.LookPath("svnadmin"); err == nil {
+		svnPath := filepath.Join(dir, "svnremote")
+		for _, args := range [][]string{{"svnadmin", "create", svnPath}, {"svn", "mkdir", "-m", "Create trunk", "file://" + svnPath + "/trunk"}} {
+			if output, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+				t.Fatalf("Failed to run %v. Error %v: %s", args, err, output)
+			}
+		}
+		svnURL = "file://" + svnPath
+		manifest += `  svnrepo:
+    type: svn
+    url: ` + svnURL + `
+    version: trunk
+`
+	}
+	reposFile := writeReposFile(t, filepath.Join(dir, "deps.repos"), manifest)
+	ws := workspace.New(filepath.Join(dir, "ws"))
+
+	locked, results, err := ws.Lock(context.Background(), workspace.LockOptions{Input: reposFile, Recursive: true})
+	if err != nil || len(workspace.Failed(results)) != 0 {
+		t.Fatalf("Expected to lock the mixed manifest. Got %v, error %v", results, err)
+	}
+	if repo := locked.Repositories["repo"]; repo.Version != headSha || repo.Ref != "main" {
+		t.Errorf("Unexpected locked git repository %v", repo)
+	}
+	config, _ := utils.ParseReposFile(reposFile)
+	if repo := locked.Repositories["tarpkg"]; !reflect.DeepEqual(repo, config.Repositories["tarpkg"]) {
+		t.Errorf("Expected the archive to be kept as it is. Got %v", repo)
+	}
+	if repo := locked.Repositories["svnrepo"]; svnURL != "" && (repo.Version != "trunk@1" || repo.Ref != "trunk") {
+		t.Errorf("Unexpected locked Subversion repository %v", repo)
+	}
+	if drift := workspace.LockDrift(config, locked); len(drift) != 0 {
+		t.Errorf("Expected no drift. Got %v", drift)
+	}
+
+	lockedFile, _ := yaml.Marshal(locked)
+	if err := os.WriteFile(reposFile+utils.LockFileSuffix, lockedFile, 0644); err != nil {
+		t.Fatal(err)
+	}
+	results, err = ws.Import(context.Background(), workspace.ImportOptions{Input: reposFile, Locked: true})
+	if err != nil || len(workspace.Failed(results)) != 0 {
+		t.Fatalf("Expected to import the locked mixed manifest. Got %v, error %v", results, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ws", "tarpkg", "README.md")); err != nil {
+		t.Errorf("Expected the archive to be extracted. Error %v", err)
+	}
+
+	tarpkg := config.Repositories["tarpkg"]
+	tarpkg.SHA256 = strings.Repeat("0", 64)
+	config.Repositories["tarpkg"] = tarpkg
+	expected := []string{"sha256 changed: tarpkg ('" + checksum + "' -> '" + tarpkg.SHA256 + "')"}
+	if drift := workspace.LockDrift(config, locked); strings.Join(drift, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected drift %v. Got %v", expected, drift)
+	}
+}
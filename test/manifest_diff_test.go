@@ -0,0 +1,72 @@
+package test
+
+import (
+	"path/filepath"
+	"ripvcs/pkg/workspace"
+	"ripvcs/utils"
+	"slices"
+	"testing"
+)
+
+func TestDiffManifests(t *testing.T) {
+	dir := t.TempDir()
+	oldFile := writeReposFile(t, filepath.Join(dir, "old.repos"), `repositories:
+  same:
+    type: git
+    url: https://example.com/same.git
+    version: main
+  moved:
+    type: git
+    url: https://example.com/moved.git
+    version: main
+  removed:
+    type: git
+    url: https://example.com/removed.git
+`)
+	newFile := writeReposFile(t, filepath.Join(dir, "new.rosinstall"), `- git:
+    local-name: same
+    uri: https://example.com/same.git
+    version: main
+- git:
+    local-name: moved
+    uri: https://example.com/fork.git
+    version: jazzy
+- git:
+    local-name: added
+    uri: https://example.com/added.git
+`)
+	oldConfig, err := utils.ParseReposFile(oldFile)
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v", oldFile, err)
+	}
+	newConfig, err := utils.ParseReposFile(newFile)
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v", newFile, err)
+	}
+
+	changes := workspace.DiffManifests(oldConfig, newConfig)
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes, got %+v", changes)
+	}
+	expected := []struct{ name, change string }{
+		{"added", workspace.ManifestAdded},
+		{"moved", workspace.ManifestChanged},
+		{"removed", workspace.ManifestRemoved},
+	}
+	for i, want := range expected {
+		if changes[i].Name != want.name || changes[i].Change != want.change {
+			t.Errorf("expected %s to be %s, got %s %s", want.name, want.change, changes[i].Name, changes[i].Change)
+		}
+	}
+	if changes[0].New == nil || changes[0].New.URL != "https://example.com/added.git" {
+		t.Errorf("expected the new entry of the added repository, got %+v", changes[0].New)
+	}
+	details := []string{"url: https://example.com/moved.git -> https://example.com/fork.git", "version: main -> jazzy"}
+	if !slices.Equal(changes[1].Details, details) {
+		t.Errorf("expected details %v, got %v", details, changes[1].Details)
+	}
+
+	if changes := workspace.DiffManifests(oldConfig, oldConfig); len(changes) != 0 {
+		t.Errorf("expected no changes between identical files, got %+v", changes)
+	}
+}
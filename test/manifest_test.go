@@ -0,0 +1,65 @@
+package test
+
+import (
+	"context"
+	"path/filepath"
+	"ripvcs/pkg/workspace"
+	"slices"
+	"testing"
+)
+
+func TestWorkspaceResolveManifest(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	root := filepath.Join(dir, "ws")
+	for _, name := range []string{"first", "nested", "stray"} {
+		runGit(t, dir, "clone", remotePath, filepath.Join(root, name))
+	}
+	writeReposFile(t, filepath.Join(root, "first", "deps.repos"), `repositories:
+  nested:
+    type: git
+    url: `+remotePath+`
+`)
+	reposFile := writeReposFile(t, filepath.Join(dir, "ws.repos"), `repositories:
+  first:
+    type: git
+    url: `+remotePath+`
+  missing:
+    type: git
+    url: `+remotePath+`
+`)
+
+	ws := workspace.New(root)
+	manifest, err := ws.ResolveManifest(context.Background(), workspace.ManifestOptions{Input: reposFile})
+	if err != nil {
+		t.Fatalf("Expected to resolve manifest. Error %v", err)
+	}
+	if !slices.Equal(manifest.Paths, []string{filepath.Join(root, "first")}) {
+		t.Errorf("Expected only the listed repository, got %v", manifest.Paths)
+	}
+	if !slices.Equal(manifest.Missing, []string{filepath.Join(root, "missing")}) {
+		t.Errorf("Expected the missing repository to be reported, got %v", manifest.Missing)
+	}
+	if !slices.Equal(manifest.Stray, []string{filepath.Join(root, "nested"), filepath.Join(root, "stray")}) {
+		t.Errorf("Expected the repositories not listed to be reported, got %v", manifest.Stray)
+	}
+
+	manifest, err = ws.ResolveManifest(context.Background(), workspace.ManifestOptions{Input: reposFile, Recursive: true})
+	if err != nil {
+		t.Fatalf("Expected to resolve manifest. Error %v", err)
+	}
+	if !slices.Equal(manifest.Paths, []string{filepath.Join(root, "first"), filepath.Join(root, "nested")}) {
+		t.Errorf("Expected the nested repository to be listed, got %v", manifest.Paths)
+	}
+	if !slices.Equal(manifest.Stray, []string{filepath.Join(root, "stray")}) {
+		t.Errorf("Expected only the stray repository not to be listed, got %v", manifest.Stray)
+	}
+
+	manifest, err = ws.ResolveManifest(context.Background(), workspace.ManifestOptions{Input: reposFile, Recursive: true, Exclude: []string{"deps.repos"}})
+	if err != nil {
+		t.Fatalf("Expected to resolve manifest. Error %v", err)
+	}
+	if len(manifest.Paths) != 1 {
+		t.Errorf("Expected the excluded .repos file not to be followed, got %v", manifest.Paths)
+	}
+}
@@ -0,0 +1,52 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"ripvcs/utils"
+	"testing"
+)
+
+func TestSetOutputFormat(t *testing.T) {
+	for _, format := range []string{utils.JSONOutput, utils.NDJSONOutput, utils.TextOutput} {
+		if err := utils.SetOutputFormat(format); err != nil {
+			t.Errorf("Expected %s to be a valid output format. Error %v", format, err)
+		}
+		if utils.GetOutputFormat() != format {
+			t.Errorf("Expected output format to be %s. Got %s", format, utils.GetOutputFormat())
+		}
+	}
+	if err := utils.SetOutputFormat("yaml"); err == nil {
+		t.Errorf("Expected to report invalid output format")
+	}
+	if utils.IsStructuredOutput() {
+		t.Errorf("Expected invalid output format to keep the previous format")
+	}
+}
+
+func TestRepoResultJSON(t *testing.T) {
+	result := utils.RepoResult{
+		Path:      "src/demos",
+		Operation: "pull",
+		Success:   false,
+		Output:    "",
+		Err:       errors.New("failed to pull"),
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("Failed to encode result. Error %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Failed to decode result. Error %v", err)
+	}
+	if decoded["path"] != "src/demos" || decoded["operation"] != "pull" || decoded["success"] != false {
+		t.Errorf("Unexpected encoded result %s", encoded)
+	}
+	if decoded["error"] != "failed to pull" {
+		t.Errorf("Expected error message to be encoded. Got %v", decoded["error"])
+	}
+	if _, ok := decoded["repository"]; ok {
+		t.Errorf("Expected repository to be omitted when not set")
+	}
+}
@@ -0,0 +1,47 @@
+package test
+
+import (
+	"context"
+	"path/filepath"
+	"ripvcs/utils"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseGitProgress(t *testing.T) {
+	progress, ok := utils.ParseGitProgress("Receiving objects:  45% (450/1000), 1.20 MiB | 2.00 MiB/s")
+	if !ok || progress.Phase != "Receiving objects" || progress.Percent != 45 {
+		t.Errorf("Failed to parse receiving objects progress. Got %v", progress)
+	}
+	progress, ok = utils.ParseGitProgress("remote: Compressing objects: 100% (3/3), done.")
+	if !ok || progress.Phase != "Compressing objects" || progress.Percent != 100 {
+		t.Errorf("Failed to parse remote progress. Got %v", progress)
+	}
+	if _, ok = utils.ParseGitProgress("Cloning into 'demos'..."); ok {
+		t.Errorf("Expected to not parse a regular line as progress")
+	}
+}
+
+func TestGitCloneProgress(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+
+	var phases []string
+	var phasesMutex sync.Mutex
+	ctx := utils.WithGitProgress(context.Background(), func(progress utils.GitProgress) {
+		phasesMutex.Lock()
+		defer phasesMutex.Unlock()
+		phases = append(phases, progress.Phase)
+	})
+	output, err := utils.RunGitCmdContext(ctx, dir, "clone", nil, "file://"+remotePath, filepath.Join(dir, "clone"))
+	if err != nil {
+		t.Fatalf("Expected to clone repository. Error %v", err)
+	}
+	if len(phases) == 0 {
+		t.Errorf("Expected progress to be reported")
+	}
+	if strings.Contains(output, "Receiving objects") {
+		t.Errorf("Expected progress lines to be removed from the output. Got %s", output)
+	}
+}
@@ -171,3 +171,24 @@ func TestParseRepositoryInfo(t *testing.T) {
 		t.Errorf("Failed to properly parse the repository info using tag")
 	}
 }
+
+func TestParseRosinstallTags(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "tagged.rosinstall")
+	content := `- git:
+    local-name: perception
+    uri: https://github.com/ros/perception.git
+    version: main
+    tags: [perception, simulation]
+`
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := utils.ParseReposFile(filePath)
+	if err != nil {
+		t.Fatalf("Expected to parse rosinstall file. Error %v", err)
+	}
+	tags := config.Repositories["perception"].Tags
+	if len(tags) != 2 || tags[0] != "perception" || tags[1] != "simulation" {
+		t.Errorf("Expected tags to be parsed, got %v", tags)
+	}
+}
@@ -0,0 +1,129 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"ripvcs/pkg/workspace"
+	"ripvcs/utils"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// createSubmoduleRemote Create a bare repository with a submodule at libs/sub pointing at the given remote
+func createSubmoduleRemote(t *testing.T, dir string, subRemotePath string) string {
+	t.Helper()
+	remotePath := filepath.Join(dir, "parent.git")
+	runGit(t, dir, "init", "--bare", "--initial-branch=main", remotePath)
+	seedPath := filepath.Join(dir, "parent-seed")
+	runGit(t, dir, "clone", remotePath, seedPath)
+	runGit(t, seedPath, "submodule", "add", subRemotePath, "libs/sub")
+	runGit(t, seedPath, "commit", "-m", "Add submodule")
+	runGit(t, seedPath, "push", "origin", "HEAD:main")
+	return remotePath
+}
+
+func TestWorkspaceSubmodules(t *testing.T) {
+	// Local submodules are only cloned when the file protocol is allowed
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+	dir := t.TempDir()
+	subRemotePath := createLocalRemote(t, dir)
+	remotePath := createSubmoduleRemote(t, dir, subRemotePath)
+	repoPath := filepath.Join(dir, "ws", "parent")
+	runGit(t, dir, "clone", remotePath, repoPath)
+	ws := workspace.New(filepath.Join(dir, "ws"))
+
+	results := ws.Status(context.Background(), []string{repoPath}, workspace.StatusOptions{Plain: true, SkipEmpty: true})
+	if len(results) != 1 || !strings.Contains(results[0].Output, "libs/sub: "+utils.SubmoduleNotInitialized) {
+		t.Fatalf("Expected the uninitialized submodule to be reported. Got %v", results)
+	}
+
+	results = ws.SubmoduleUpdate(context.Background(), []string{repoPath}, workspace.SubmoduleOptions{})
+	if len(workspace.Failed(results)) != 0 {
+		t.Fatalf("Expected to update the submodules. Got %v", results)
+	}
+	subPath := filepath.Join(repoPath, "libs", "sub")
+	if !utils.IsGitRepository(subPath) || !utils.IsGitSubmodule(subPath) {
+		t.Fatalf("Expected %s to be a submodule checkout", subPath)
+	}
+	// Submodule checkouts are managed by their parent repository
+	paths, err := ws.Repositories()
+	if err != nil || !slices.Equal(paths, []string{repoPath}) {
+		t.Fatalf("Expected to find the repository without its submodule. Got %v, error %v", paths, err)
+	}
+	ws.Discovery.IncludeSubmodules = true
+	paths, err = ws.Repositories()
+	if err != nil || len(paths) != 2 {
+		t.Fatalf("Expected to find the repository and its submodule. Got %v, error %v", paths, err)
+	}
+	if results := ws.Status(context.Background(), paths, workspace.StatusOptions{Plain: true, SkipEmpty: true}); len(results) != 0 {
+		t.Errorf("Expected the up to date submodule to be clean. Got %v", results)
+	}
+
+	// A new commit recorded upstream leaves the submodule out of date until it is pulled
+	seedPath := filepath.Join(dir, "seed")
+	if err := os.WriteFile(filepath.Join(seedPath, "CHANGELOG.md"), []byte("change\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, seedPath, "add", "CHANGELOG.md")
+	runGit(t, seedPath, "commit", "-m", "Second commit")
+	runGit(t, seedPath, "push", "origin", "HEAD:main")
+	parentSeed := filepath.Join(dir, "parent-seed")
+	runGit(t, filepath.Join(parentSeed, "libs", "sub"), "pull", "origin", "main")
+	runGit(t, parentSeed, "commit", "-am", "Update submodule")
+	runGit(t, parentSeed, "push", "origin", "HEAD:main")
+
+	results = ws.Pull(context.Background(), paths, workspace.PullOptions{RecurseSubmodules: true})
+	if len(workspace.Failed(results)) != 0 || len(results) != 2 || !results[1].Skipped {
+		t.Fatalf("Expected to pull the repository along with its submodule. Got %v", results)
+	}
+	if sha := runGit(t, subPath, "rev-parse", "HEAD"); sha != runGit(t, seedPath, "rev-parse", "HEAD") {
+		t.Errorf("Expected the submodule to be updated to the recorded commit. Got %s", sha)
+	}
+
+	runGit(t, subPath, "checkout", "HEAD~1")
+	results = ws.Status(context.Background(), []string{repoPath}, workspace.StatusOptions{Plain: true})
+	if len(results) != 1 || !strings.Contains(results[0].Output, "libs/sub: "+utils.SubmoduleOutOfDate) {
+		t.Errorf("Expected the out of date submodule to be reported. Got %v", results)
+	}
+
+	config, results := ws.Export(context.Background(), paths, workspace.ExportOptions{})
+	if len(workspace.Failed(results)) != 0 {
+		t.Fatalf("Expected to export the repositories. Got %v", results)
+	}
+	if repo := config.Repositories["parent/libs/sub"]; repo.URL != subRemotePath || !utils.IsValidSha(repo.Version) {
+		t.Errorf("Expected the submodule to be exported with its commit. Got %v", repo)
+	}
+
+	// The detached submodule checkout is left to its parent also without recursing
+	for _, results := range [][]workspace.Result{
+		ws.Pull(context.Background(), paths, workspace.PullOptions{}),
+		ws.Sync(context.Background(), paths, workspace.PullOptions{}),
+	} {
+		if len(workspace.Failed(results)) != 0 || len(results) != 2 || !results[1].Skipped {
+			t.Errorf("Expected the submodule checkout to be skipped. Got %v", results)
+		}
+	}
+
+	// Submodule checkouts are part of the listed repository, not extra repositories
+	reposFile := writeReposFile(t, filepath.Join(dir, "deps.repos"), `repositories:
+  parent:
+    type: git
+    url: `+remotePath+`
+    version: main
+`)
+	for _, includeSubmodules := range []bool{false, true} {
+		ws.Discovery.IncludeSubmodules = includeSubmodules
+		manifest, err := ws.ResolveManifest(context.Background(), workspace.ManifestOptions{Input: reposFile})
+		if err != nil || len(manifest.Stray) != 0 {
+			t.Errorf("Expected no repositories not listed with submodules included %v. Got %v, error %v", includeSubmodules, manifest, err)
+		}
+		entries, err := ws.Check(context.Background(), workspace.ManifestOptions{Input: reposFile})
+		if err != nil || len(entries) != 1 || entries[0].Path != repoPath {
+			t.Errorf("Expected only the listed repository to be checked with submodules included %v. Got %v, error %v", includeSubmodules, entries, err)
+		}
+	}
+}
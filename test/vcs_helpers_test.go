@@ -0,0 +1,147 @@
+package test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"ripvcs/pkg/workspace"
+	"ripvcs/utils"
+	"testing"
+)
+
+func TestGetVCS(t *testing.T) {
+	for _, repoType := range []string{"git", "hg", "svn"} {
+		vcs, err := utils.GetVCS(repoType)
+		if err != nil || vcs.Type() != repoType {
+			t.Errorf("Expected %s repositories to be supported. Error %v", repoType, err)
+		}
+	}
+	if _, err := utils.GetVCS("bzr"); err == nil {
+		t.Errorf("Expected bzr repositories to be unsupported")
+	}
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "hgrepo", ".hg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if vcs, err := utils.DetectVCS(filepath.Join(dir, "hgrepo")); err != nil || vcs.Type() != "hg" {
+		t.Errorf("Expected to detect a Mercurial repository. Error %v", err)
+	}
+	if _, err := utils.DetectVCS(dir); err == nil {
+		t.Errorf("Expected a plain directory not to be detected as a repository")
+	}
+	if repos, err := utils.ListRepositories(dir); err != nil || len(repos) != 1 {
+		t.Errorf("Expected to find the Mercurial repository. Got %v, error %v", repos, err)
+	}
+}
+
+// runHg Run a hg command failing the test on errors
+func runHg(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("hg", append([]string{"--config", "ui.username=ripvcs"}, args...)...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to run hg %v. Error %v: %s", args, err, output)
+	}
+}
+
+func TestHgWorkspace(t *testing.T) {
+	if _, err := exec.LookPath("hg"); err != nil {
+		t.Skip("hg is not installed")
+	}
+	dir := t.TempDir()
+	remotePath := filepath.Join(dir, "remote")
+	runHg(t, dir, "init", remotePath)
+	if err := os.WriteFile(filepath.Join(remotePath, "README.md"), []byte("seed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runHg(t, remotePath, "add", "README.md")
+	runHg(t, remotePath, "commit", "-m", "Initial commit")
+
+	reposFile := writeReposFile(t, filepath.Join(dir, "deps.repos"), `repositories:
+  hgrepo:
+    type: hg
+    url: `+remotePath+`
+    version: default
+`)
+	ws := workspace.New(filepath.Join(dir, "ws"))
+	if results := ws.Validate(context.Background(), &utils.Config{Repositories: map[string]utils.Repository{
+		"hgrepo": {Type: "hg", URL: remotePath, Version: "default"},
+	}}, workspace.ValidateOptions{}); len(workspace.Failed(results)) != 0 {
+		t.Errorf("Expected the Mercurial repository to be valid. Got %v", results)
+	}
+	if results, err := ws.Import(context.Background(), workspace.ImportOptions{Input: reposFile}); err != nil {
+		t.Fatalf("Expected to import the Mercurial repository. Got %v, error %v", results, err)
+	}
+
+	paths, _ := ws.Repositories()
+	if len(paths) != 1 {
+		t.Fatalf("Expected to find the Mercurial repository. Got %v", paths)
+	}
+	if results := ws.Status(context.Background(), paths, workspace.StatusOptions{SkipEmpty: true}); len(results) != 0 {
+		t.Errorf("Expected the clean repository to be skipped. Got %v", results)
+	}
+	if results := ws.Pull(context.Background(), paths, workspace.PullOptions{}); len(workspace.Failed(results)) != 0 {
+		t.Errorf("Expected to pull the Mercurial repository. Got %v", results)
+	}
+	config, _ := ws.Export(context.Background(), paths, workspace.ExportOptions{})
+	if repo := config.Repositories["hgrepo"]; repo.Type != "hg" || repo.URL != remotePath || repo.Version != "default" {
+		t.Errorf("Unexpected exported repository %v", repo)
+	}
+}
+
+func TestSvnCheckoutTarget(t *testing.T) {
+	cases := []struct{ version, url, revision string }{
+		{"", "https://svn.example.com/repo", ""},
+		{"1234", "https://svn.example.com/repo", "1234"},
+		{"r1234", "https://svn.example.com/repo", "1234"},
+		{"branches/stable", "https://svn.example.com/repo/branches/stable", ""},
+		{"branches/stable@1234", "https://svn.example.com/repo/branches/stable", "1234"},
+	}
+	for _, c := range cases {
+		url, revision := utils.SvnCheckoutTarget("https://svn.example.com/repo/", c.version)
+		if url != c.url || revision != c.revision {
+			t.Errorf("Expected version '%s' to check out %s at '%s'. Got %s at '%s'", c.version, c.url, c.revision, url, revision)
+		}
+	}
+}
+
+func TestSvnWorkspace(t *testing.T) {
+	if _, err := exec.LookPath("svnadmin"); err != nil {
+		t.Skip("svn is not installed")
+	}
+	dir := t.TempDir()
+	remotePath := filepath.Join(dir, "remote")
+	for _, args := range [][]string{{"svnadmin", "create", remotePath}, {"svn", "mkdir", "-m", "Create trunk", "file://" + remotePath + "/trunk"}} {
+		if output, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
+			t.Fatalf("Failed to run %v. Error %v: %s", args, err, output)
+		}
+	}
+	remoteURL := "file://" + remotePath
+
+	reposFile := writeReposFile(t, filepath.Join(dir, "deps.repos"), `repositories:
+  svnrepo:
+    type: svn
+    url: `+remoteURL+`
+    version: trunk
+`)
+	ws := workspace.New(filepath.Join(dir, "ws"))
+	if results, err := ws.Import(context.Background(), workspace.ImportOptions{Input: reposFile}); err != nil {
+		t.Fatalf("Expected to check out the Subversion repository. Got %v, error %v", results, err)
+	}
+	paths, _ := ws.Repositories()
+	if len(paths) != 1 {
+		t.Fatalf("Expected to find the Subversion working copy. Got %v", paths)
+	}
+	if results := ws.Status(context.Background(), paths, workspace.StatusOptions{SkipEmpty: true}); len(results) != 0 {
+		t.Errorf("Expected the clean working copy to be skipped. Got %v", results)
+	}
+	if results := ws.Pull(context.Background(), paths, workspace.PullOptions{}); len(workspace.Failed(results)) != 0 {
+		t.Errorf("Expected to update the working copy. Got %v", results)
+	}
+	config, _ := ws.Export(context.Background(), paths, workspace.ExportOptions{})
+	if repo := config.Repositories["svnrepo"]; repo.Type != "svn" || repo.URL != remoteURL+"/trunk" || repo.Version != "1" {
+		t.Errorf("Unexpected exported repository %v", repo)
+	}
+}
@@ -0,0 +1,314 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"ripvcs/pkg/workspace"
+	"ripvcs/utils"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// runGit Run a git command failing the test on errors
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmdArgs := append([]string{"-c", "user.name=ripvcs", "-c", "user.email=ripvcs@example.com"}, args...)
+	cmd := exec.Command("git", cmdArgs...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Failed to run git %v. Error %v: %s", args, err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// createLocalRemote Create a bare repository with a single commit on the main branch
+func createLocalRemote(t *testing.T, dir string) string {
+	t.Helper()
+	remotePath := filepath.Join(dir, "remote.git")
+	runGit(t, dir, "init", "--bare", "--initial-branch=main", remotePath)
+
+	seedPath := filepath.Join(dir, "seed")
+	runGit(t, dir, "clone", remotePath, seedPath)
+	if err := os.WriteFile(filepath.Join(seedPath, "README.md"), []byte("seed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, seedPath, "add", "README.md")
+	runGit(t, seedPath, "commit", "-m", "Initial commit")
+	runGit(t, seedPath, "push", "origin", "HEAD:main")
+	return remotePath
+}
+
+// writeReposFile Write a .repos file with the given content
+func writeReposFile(t *testing.T, path string, content string) string {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestWorkspaceImport(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	reposFile := writeReposFile(t, filepath.Join(dir, "deps.repos"), `repositories:
+  src/first:
+    type: git
+    url: `+remotePath+`
+    version: main
+  src/second:
+    type: git
+    url: `+remotePath+`
+    version: main
+`)
+
+	var events []workspace.Event
+	var eventsMutex sync.Mutex
+	ws := workspace.New(filepath.Join(dir, "ws"))
+	ws.Observer = workspace.ObserverFunc(func(event workspace.Event) {
+		eventsMutex.Lock()
+		defer eventsMutex.Unlock()
+		events = append(events, event)
+	})
+
+	results, err := ws.Import(context.Background(), workspace.ImportOptions{Input: reposFile, Retries: 1})
+	if err != nil {
+		t.Fatalf("Expected to import repositories. Error %v", err)
+	}
+	if len(results) != 2 || len(workspace.Failed(results)) != 0 {
+		t.Errorf("Expected two successful results. Got %v", results)
+	}
+	finished := 0
+	for _, event := range events {
+		if event.Kind == workspace.RepoFinished {
+			finished++
+		}
+	}
+	if finished != 2 {
+		t.Errorf("Expected to be notified about two finished repositories. Got %d", finished)
+	}
+
+	results, err = ws.Import(context.Background(), workspace.ImportOptions{Input: reposFile, Retries: 1})
+	if err != nil {
+		t.Fatalf("Expected to import repositories again. Error %v", err)
+	}
+	for _, result := range results {
+		if !result.Skipped {
+			t.Errorf("Expected %s to be skipped", result.Path)
+		}
+	}
+
+	_, err = ws.Import(context.Background(), workspace.ImportOptions{Input: filepath.Join(dir, "missing.repos")})
+	if err == nil {
+		t.Errorf("Expected to report a missing .repos file")
+	}
+}
+
+func TestWorkspaceOperations(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	reposFile := writeReposFile(t, filepath.Join(dir, "deps.repos"), `repositories:
+  repo:
+    type: git
+    url: `+remotePath+`
+    version: main
+`)
+	ws := workspace.New(filepath.Join(dir, "ws"))
+	if _, err := ws.Import(context.Background(), workspace.ImportOptions{Input: reposFile}); err != nil {
+		t.Fatalf("Expected to import repositories. Error %v", err)
+	}
+
+	paths, err := ws.Repositories()
+	if err != nil || len(paths) != 1 {
+		t.Fatalf("Expected to find one repository. Got %v, error %v", paths, err)
+	}
+
+	if results := ws.Status(context.Background(), paths, workspace.StatusOptions{SkipEmpty: true}); len(results) != 0 {
+		t.Errorf("Expected clean repositories to be skipped. Got %v", results)
+	}
+	if results := ws.Status(context.Background(), paths, workspace.StatusOptions{Plain: true}); len(results) != 1 || !results[0].Success {
+		t.Errorf("Expected to get the status of the repository. Got %v", results)
+	}
+	if results := ws.Log(context.Background(), paths, workspace.LogOptions{Oneline: true, NumCommits: 1}); len(results) != 1 || !strings.Contains(results[0].Output, "Initial commit") {
+		t.Errorf("Expected to get the log of the repository. Got %v", results)
+	}
+	if results := ws.Pull(context.Background(), paths, workspace.PullOptions{}); len(workspace.Failed(results)) != 0 {
+		t.Errorf("Expected to pull the repository. Got %v", results)
+	}
+
+	config, results := ws.Export(context.Background(), paths, workspace.ExportOptions{})
+	if len(workspace.Failed(results)) != 0 {
+		t.Fatalf("Expected to export the repository. Got %v", results)
+	}
+	if repo := config.Repositories["repo"]; repo.URL != remotePath || repo.Version != "main" {
+		t.Errorf("Unexpected exported repository %v", repo)
+	}
+
+	result := workspace.Switch(context.Background(), paths[0], workspace.SwitchOptions{Version: "missing"})
+	if result.Success || result.Err == nil {
+		t.Errorf("Expected to fail to switch to a missing branch")
+	}
+	result = workspace.Switch(context.Background(), paths[0], workspace.SwitchOptions{Version: "feature", Create: true})
+	if !result.Success {
+		t.Errorf("Expected to create a new branch. Error %v", result.Err)
+	}
+}
+
+func TestWorkspaceExportNested(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	root := filepath.Join(dir, "ws")
+	for _, name := range []string{"common", "stack/common", "stack/tools/nested"} {
+		runGit(t, dir, "clone", remotePath, filepath.Join(root, name))
+	}
+	ws := workspace.New(root)
+	paths, err := ws.Repositories()
+	if err != nil || len(paths) != 3 {
+		t.Fatalf("Expected to find three repositories. Got %v, error %v", paths, err)
+	}
+
+	config, results := ws.Export(context.Background(), paths, workspace.ExportOptions{})
+	if failed := workspace.Failed(results); len(failed) != 0 {
+		t.Fatalf("Expected to export the repositories. Got %v", failed)
+	}
+	for _, name := range []string{"common", "stack/common", "stack/tools/nested"} {
+		if repo, ok := config.Repositories[name]; !ok || repo.URL != remotePath {
+			t.Errorf("Expected %s to be exported under its relative path. Got %v", name, config.Repositories)
+		}
+	}
+
+	// The root repository is exported under its directory name, which is also taken by a nested one
+	runGit(t, dir, "clone", remotePath, filepath.Join(dir, "top"))
+	runGit(t, dir, "clone", remotePath, filepath.Join(dir, "top", "top"))
+	ws = workspace.New(filepath.Join(dir, "top"))
+	config, results = ws.Export(context.Background(), []string{ws.Root, filepath.Join(ws.Root, "top")}, workspace.ExportOptions{})
+	if failed := workspace.Failed(results); len(failed) != 2 || !errors.Is(failed[0].Err, workspace.ErrExportConflict) {
+		t.Fatalf("Expected a conflicting export name. Got %v", results)
+	}
+	if len(config.Repositories) != 0 {
+		t.Errorf("Expected conflicting repositories not to be exported. Got %v", config.Repositories)
+	}
+}
+
+func TestWorkspaceRemotes(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	forkPath := filepath.Join(dir, "fork.git")
+	runGit(t, dir, "clone", "--bare", remotePath, forkPath)
+	reposFile := writeReposFile(t, filepath.Join(dir, "deps.repos"), `repositories:
+  repo:
+    type: git
+    url: `+remotePath+`
+    version: main
+    remotes:
+      fork: `+forkPath+`
+`)
+	ws := workspace.New(filepath.Join(dir, "ws"))
+	if results, err := ws.Import(context.Background(), workspace.ImportOptions{Input: reposFile}); err != nil || len(workspace.Failed(results)) != 0 {
+		t.Fatalf("Expected to import the repository. Got %v, error %v", results, err)
+	}
+	repoPath := filepath.Join(ws.Root, "repo")
+	if branches := runGit(t, repoPath, "branch", "-r"); !strings.Contains(branches, "fork/main") {
+		t.Errorf("Expected the fork remote to be fetched. Got %s", branches)
+	}
+
+	config, results := ws.Export(context.Background(), []string{repoPath}, workspace.ExportOptions{})
+	if len(workspace.Failed(results)) != 0 {
+		t.Fatalf("Expected to export the repository. Got %v", results)
+	}
+	if remotes := config.Repositories["repo"].Remotes; len(remotes) != 1 || remotes["fork"] != forkPath {
+		t.Errorf("Expected the fork remote to be exported. Got %v", remotes)
+	}
+
+	runGit(t, repoPath, "branch", "--set-upstream-to", "fork/main")
+	results = ws.Status(context.Background(), []string{repoPath}, workspace.StatusOptions{Tracking: true})
+	if len(results) != 1 || !strings.HasPrefix(results[0].Output, "Tracking: fork/main\n") {
+		t.Errorf("Expected the status to show the tracked remote branch. Got %v", results)
+	}
+}
+
+func TestWorkspaceExportStrict(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	repoPath := filepath.Join(dir, "ws", "repo")
+	runGit(t, dir, "clone", remotePath, repoPath)
+	runGit(t, repoPath, "tag", "v1.0")
+	runGit(t, repoPath, "push", "origin", "v1.0")
+	ws := workspace.New(filepath.Join(dir, "ws"))
+
+	config, results := ws.Export(context.Background(), []string{repoPath}, workspace.ExportOptions{ExactWithTags: true, Strict: true})
+	if len(workspace.Failed(results)) != 0 {
+		t.Fatalf("Expected to export the pushed repository. Got %v", results)
+	}
+	if version := config.Repositories["repo"].Version; version != "v1.0" {
+		t.Errorf("Expected the tag pointing at HEAD to be exported. Got %s", version)
+	}
+
+	if err := os.WriteFile(filepath.Join(repoPath, "local.txt"), []byte("local\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repoPath, "add", "local.txt")
+	runGit(t, repoPath, "commit", "-m", "Local commit")
+	sha := runGit(t, repoPath, "rev-parse", "HEAD")
+	config, results = ws.Export(context.Background(), []string{repoPath}, workspace.ExportOptions{ExactWithTags: true})
+	if len(workspace.Failed(results)) != 0 || config.Repositories["repo"].Version != sha {
+		t.Errorf("Expected the commit hash to be exported without a tag. Got %v", results)
+	}
+
+	_, results = ws.Export(context.Background(), []string{repoPath}, workspace.ExportOptions{Strict: true})
+	if failed := workspace.Failed(results); len(failed) != 1 || !errors.Is(failed[0].Err, workspace.ErrUnpublished) ||
+		!strings.Contains(failed[0].Err.Error(), "commits not pushed to origin") {
+		t.Errorf("Expected the unpushed commit to fail the strict export. Got %v", results)
+	}
+
+	// Commits pushed by others and not fetched yet do not make HEAD unpushed
+	otherPath := filepath.Join(dir, "other")
+	runGit(t, dir, "clone", remotePath, otherPath)
+	runGit(t, otherPath, "commit", "--allow-empty", "-m", "Pushed commit")
+	runGit(t, otherPath, "push", "origin", "HEAD")
+	behindPath := filepath.Join(dir, "ws", "behind")
+	runGit(t, dir, "clone", remotePath, behindPath)
+	runGit(t, otherPath, "commit", "--allow-empty", "-m", "Not fetched commit")
+	runGit(t, otherPath, "push", "origin", "HEAD")
+	_, results = ws.Export(context.Background(), []string{behindPath}, workspace.ExportOptions{Strict: true})
+	if len(workspace.Failed(results)) != 0 {
+		t.Errorf("Expected the repository behind origin to be exported. Got %v", results)
+	}
+}
+
+func TestWorkspaceCancelled(t *testing.T) {
+	dir := t.TempDir()
+	remotePath := createLocalRemote(t, dir)
+	reposFile := writeReposFile(t, filepath.Join(dir, "deps.repos"), `repositories:
+  repo:
+    type: git
+    url: `+remotePath+`
+    version: main
+`)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ws := workspace.New(filepath.Join(dir, "ws"))
+	results, err := ws.Import(ctx, workspace.ImportOptions{Input: reposFile})
+	if err == nil {
+		t.Errorf("Expected to report the import as interrupted")
+	}
+	if len(results) != 1 || !workspace.Interrupted(results[0]) {
+		t.Errorf("Expected the repository to be reported as interrupted. Got %v", results)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ws", "repo")); !os.IsNotExist(err) {
+		t.Errorf("Expected no partial clone to be left behind")
+	}
+
+	status, err := utils.CloneGitRepo(ctx, utils.CloneOptions{URL: remotePath, Path: filepath.Join(dir, "cancelled")})
+	if status != utils.FailedClone || err == nil {
+		t.Errorf("Expected clone with a cancelled context to fail")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cancelled")); !os.IsNotExist(err) {
+		t.Errorf("Expected no partial clone to be left behind")
+	}
+}
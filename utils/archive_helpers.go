@@ -0,0 +1,323 @@
+package utils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteTarGz Write all the files inside a directory to a gzip compressed tar archive
+func WriteTarGz(archivePath string, dir string) (err error) {
+	file, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	gzipWriter := gzip.NewWriter(file)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			return fmt.Errorf("unsupported file %s", path)
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		relPath, _ := filepath.Rel(dir, path)
+		header.Name = filepath.ToSlash(relPath)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		content, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer content.Close()
+		_, err = io.Copy(tarWriter, content)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+// ExtractTarGz Extract a gzip compressed tar archive into a directory
+func ExtractTarGz(archivePath string, dir string) error {
+	return ExtractArchive(archivePath, "tar", dir, 0)
+}
+
+// ExtractArchive Extract a tar archive, optionally gzip or bzip2 compressed, or a zip archive
+// into a directory, removing the given number of leading path components of each entry
+func ExtractArchive(archivePath string, format string, dir string, stripComponents int) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	var err error
+	switch format {
+	case "tar":
+		err = extractTar(archivePath, dir, stripComponents)
+	case "zip":
+		err = extractZip(archivePath, dir, stripComponents)
+	default:
+		err = fmt.Errorf("unsupported archive format %s", format)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid archive %s. Error: %w", archivePath, err)
+	}
+	return nil
+}
+
+// extractTar Extract a tar archive detecting its compression
+func extractTar(archivePath string, dir string, stripComponents int) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	buffered := bufio.NewReader(file)
+	magic, _ := buffered.Peek(3)
+	var reader io.Reader = buffered
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gzipReader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		reader = bzip2.NewReader(buffered)
+	}
+
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		name, ok := stripArchivePath(header.Name, stripComponents)
+		if !ok {
+			continue
+		}
+		targetPath, err := archiveTargetPath(dir, name)
+		if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(targetPath, 0755)
+		case tar.TypeReg:
+			err = writeArchiveFile(targetPath, tarReader, header.FileInfo().Mode())
+		case tar.TypeSymlink:
+			err = writeArchiveSymlink(dir, targetPath, header.Linkname)
+		case tar.TypeXGlobalHeader:
+		default:
+			err = fmt.Errorf("unsupported entry %s", header.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// extractZip Extract a zip archive
+func extractZip(archivePath string, dir string, stripComponents int) error {
+	zipReader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer zipReader.Close()
+
+	for _, file := range zipReader.File {
+		name, ok := stripArchivePath(file.Name, stripComponents)
+		if !ok {
+			continue
+		}
+		targetPath, err := archiveTargetPath(dir, name)
+		if err != nil {
+			return err
+		}
+		mode := file.Mode()
+		switch {
+		case mode.IsDir():
+			err = os.MkdirAll(targetPath, 0755)
+		case mode&fs.ModeSymlink != 0:
+			err = extractZipSymlink(dir, targetPath, file)
+		case mode.IsRegular():
+			err = extractZipFile(targetPath, file)
+		default:
+			err = fmt.Errorf("unsupported entry %s", file.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractZipFile(targetPath string, file *zip.File) error {
+	content, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	return writeArchiveFile(targetPath, content, file.Mode())
+}
+
+func extractZipSymlink(dir string, targetPath string, file *zip.File) error {
+	content, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	linkname, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	return writeArchiveSymlink(dir, targetPath, string(linkname))
+}
+
+// stripArchivePath Remove leading components of an entry name. Entries with fewer components are skipped
+func stripArchivePath(name string, stripComponents int) (string, bool) {
+	name = strings.TrimPrefix(name, "./")
+	if stripComponents <= 0 {
+		return name, name != ""
+	}
+	components := strings.Split(strings.Trim(name, "/"), "/")
+	if len(components) <= stripComponents {
+		return "", false
+	}
+	return strings.Join(components[stripComponents:], "/"), true
+}
+
+// archiveTargetPath Get where an archive entry is extracted, rejecting entries outside of dir,
+// also when they would be written through the symbolic links extracted before
+func archiveTargetPath(dir string, name string) (string, error) {
+	targetPath := filepath.Join(dir, filepath.FromSlash(name))
+	if !isWithinDir(dir, targetPath) {
+		return "", fmt.Errorf("invalid entry %s in archive", name)
+	}
+	relPath, _ := filepath.Rel(dir, targetPath)
+	if !resolvesWithinDir(dir, relPath) {
+		return "", fmt.Errorf("invalid entry %s in archive", name)
+	}
+	return targetPath, nil
+}
+
+// maxArchiveLinks Maximum number of symbolic links followed while resolving an archive entry
+const maxArchiveLinks = 255
+
+// resolvesWithinDir Check if a path relative to dir stays inside dir once the symbolic links
+// already extracted are followed. Links are resolved one component at a time, before any ".."
+// is applied, as the file system does
+func resolvesWithinDir(dir string, relPath string) bool {
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false
+	}
+	links := 0
+	resolved, err := resolveArchiveLinks(realDir, relPath, &links)
+	return err == nil && isWithinDir(realDir, resolved)
+}
+
+// resolveArchiveLinks Follow the components of a relative path starting at an already resolved directory
+func resolveArchiveLinks(current string, relPath string, links *int) (string, error) {
+	for _, component := range strings.Split(filepath.ToSlash(relPath), "/") {
+		switch component {
+		case "", ".":
+		case "..":
+			current = filepath.Dir(current)
+		default:
+			next := filepath.Join(current, component)
+			info, err := os.Lstat(next)
+			if err != nil || info.Mode()&fs.ModeSymlink == 0 {
+				current = next
+				continue
+			}
+			if *links++; *links > maxArchiveLinks {
+				return "", fmt.Errorf("too many links resolving %s", relPath)
+			}
+			linkname, err := os.Readlink(next)
+			if err != nil {
+				return "", err
+			}
+			if filepath.IsAbs(linkname) {
+				current = filepath.VolumeName(linkname) + string(filepath.Separator)
+			}
+			if current, err = resolveArchiveLinks(current, linkname, links); err != nil {
+				return "", err
+			}
+		}
+	}
+	return current, nil
+}
+
+// isWithinDir Check if path is dir or any path inside it
+func isWithinDir(dir string, path string) bool {
+	dir = filepath.Clean(dir)
+	path = filepath.Clean(path)
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// writeArchiveFile Write the content of an archive entry to a file
+func writeArchiveFile(path string, content io.Reader, mode fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// writeArchiveSymlink Create a symbolic link of an archive entry, rejecting links pointing outside of dir
+func writeArchiveSymlink(dir string, path string, linkname string) error {
+	relParent, _ := filepath.Rel(dir, filepath.Dir(path))
+	if filepath.IsAbs(linkname) || !isWithinDir(dir, filepath.Join(filepath.Dir(path), linkname)) ||
+		!resolvesWithinDir(dir, relParent+"/"+linkname) {
+		return fmt.Errorf("invalid link %s to %s in archive", path, linkname)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.Symlink(linkname, path)
+}
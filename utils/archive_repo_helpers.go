@@ -0,0 +1,292 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jesseduffield/yaml"
+)
+
+// ArchiveStampFile File written inside extracted tar and zip repositories to detect changes
+const ArchiveStampFile = ".rv-archive"
+
+// archiveStamp Archive a directory was extracted from and the checksum of each extracted file
+type archiveStamp struct {
+	URL             string            `yaml:"url"`
+	SHA256          string            `yaml:"sha256"`
+	StripComponents int               `yaml:"strip-components,omitempty"`
+	Files           map[string]string `yaml:"files"`
+}
+
+var sha256Regex = regexp.MustCompile(`^[a-fA-F0-9]{64}$`)
+
+// archiveVCS Repositories downloaded as a tar or zip archive
+type archiveVCS struct {
+	format string
+}
+
+func (vcs archiveVCS) Type() string { return vcs.format }
+
+// IsRepository Extracted archives are not repositories, they are never discovered
+func (archiveVCS) IsRepository(dir string) bool { return false }
+
+func (vcs archiveVCS) Status(ctx context.Context, path string, plain bool) (string, error) {
+	return "", vcs.unsupported("status")
+}
+
+func (archiveVCS) IsCleanStatus(output string, plain bool) bool { return true }
+
+func (vcs archiveVCS) Log(ctx context.Context, path string, oneline bool, numCommits int) (string, error) {
+	return "", vcs.unsupported("log")
+}
+
+func (vcs archiveVCS) Pull(ctx context.Context, path string) (string, error) {
+	return "", vcs.unsupported("pull")
+}
+
+func (vcs archiveVCS) Info(ctx context.Context, path string, useCommit bool) (Repository, error) {
+	return Repository{}, vcs.unsupported("export")
+}
+
+func (vcs archiveVCS) unsupported(operation string) error {
+	return fmt.Errorf("%s is not supported for %s repositories", operation, vcs.format)
+}
+
+// Validate Check that the archive is reachable without downloading it
+func (vcs archiveVCS) Validate(ctx context.Context, repo Repository, enablePrompt bool) error {
+	if repo.SHA256 != "" && !sha256Regex.MatchString(repo.SHA256) {
+		return fmt.Errorf("invalid sha256 '%s' of %s archive '%s'", repo.SHA256, vcs.format, repo.URL)
+	}
+	if !isRemoteArchive(repo.URL) {
+		if _, err := os.Stat(localArchivePath(repo.URL)); err != nil {
+			return fmt.Errorf("failed to find %s archive '%s'. Error: %w", vcs.format, repo.URL, err)
+		}
+		return nil
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, repo.URL, nil)
+	if err != nil {
+		return err
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to contact %s archive '%s'. Error: %w", vcs.format, repo.URL, err)
+	}
+	response.Body.Close()
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("failed to contact %s archive '%s'. Status: %s", vcs.format, repo.URL, response.Status)
+	}
+	return nil
+}
+
+// Clone Download and extract the archive. An existing directory is checked against the
+// archive it was extracted from, and replaced if a different archive is requested
+func (vcs archiveVCS) Clone(ctx context.Context, opts CloneOptions) (int, error) {
+	if opts.SHA256 != "" && !sha256Regex.MatchString(opts.SHA256) {
+		return FailedClone, fmt.Errorf("invalid sha256 '%s' of %s archive '%s'", opts.SHA256, vcs.format, opts.URL)
+	}
+
+	_, statErr := os.Stat(opts.Path)
+	existing := statErr == nil
+	if existing && !opts.OverwriteExisting {
+		stamp, err := readArchiveStamp(opts.Path)
+		if err != nil {
+			return FailedClone, err
+		}
+		if drift := archiveDrift(opts.Path, stamp); len(drift) > 0 {
+			return FailedClone, fmt.Errorf("%s no longer matches the archive '%s' it was extracted from. Use force to overwrite it:\n  %s",
+				opts.Path, stamp.URL, strings.Join(drift, "\n  "))
+		}
+		if stamp.URL == opts.URL && stamp.StripComponents == opts.StripComponents && (opts.SHA256 == "" || strings.EqualFold(stamp.SHA256, opts.SHA256)) {
+			return SkippedClone, nil
+		}
+	}
+
+	// Extract next to the final path so it can be moved in place once complete
+	parentDir := filepath.Dir(filepath.Clean(opts.Path))
+	if err := os.MkdirAll(parentDir, 0755); err != nil {
+		return FailedClone, err
+	}
+	tempDir, err := os.MkdirTemp(parentDir, ".rv-archive-")
+	if err != nil {
+		return FailedClone, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	archivePath := filepath.Join(tempDir, "archive")
+	checksum, err := downloadArchive(ctx, opts.URL, archivePath)
+	if err != nil {
+		return FailedClone, err
+	}
+	if opts.SHA256 != "" && !strings.EqualFold(checksum, opts.SHA256) {
+		return FailedClone, fmt.Errorf("checksum mismatch of %s archive '%s'. Expected %s, got %s", vcs.format, opts.URL, opts.SHA256, checksum)
+	}
+
+	extractPath := filepath.Join(tempDir, "extracted")
+	if err := ExtractArchive(archivePath, vcs.format, extractPath, opts.StripComponents); err != nil {
+		return FailedClone, err
+	}
+	files, err := archiveChecksums(extractPath)
+	if err != nil {
+		return FailedClone, err
+	}
+	stamp := archiveStamp{URL: opts.URL, SHA256: checksum, StripComponents: opts.StripComponents, Files: files}
+	stampData, err := yaml.Marshal(stamp)
+	if err != nil {
+		return FailedClone, err
+	}
+	if err := os.WriteFile(filepath.Join(extractPath, ArchiveStampFile), stampData, 0644); err != nil {
+		return FailedClone, err
+	}
+
+	if existing {
+		if err := os.RemoveAll(opts.Path); err != nil {
+			return FailedClone, fmt.Errorf("failed to remove existing cloning path %s. Error: %w", opts.Path, err)
+		}
+	}
+	if err := os.Rename(extractPath, opts.Path); err != nil {
+		return FailedClone, err
+	}
+	if existing && !opts.OverwriteExisting {
+		return SwitchedBranch, nil
+	}
+	return SuccessfullClone, nil
+}
+
+// isRemoteArchive Check if the archive has to be downloaded
+func isRemoteArchive(url string) bool {
+	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")
+}
+
+// localArchivePath Get the path of a local archive given as path or file:// URL
+func localArchivePath(url string) string {
+	return strings.TrimPrefix(url, "file://")
+}
+
+// downloadArchive Copy an archive from a http(s) URL or a local path and get its checksum
+func downloadArchive(ctx context.Context, url string, archivePath string) (string, error) {
+	var content io.ReadCloser
+	if isRemoteArchive(url) {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return "", err
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			return "", fmt.Errorf("failed to download %s. Error: %w", url, err)
+		}
+		if response.StatusCode >= http.StatusBadRequest {
+			response.Body.Close()
+			return "", fmt.Errorf("failed to download %s. Status: %s", url, response.Status)
+		}
+		content = response.Body
+	} else {
+		file, err := os.Open(localArchivePath(url))
+		if err != nil {
+			return "", fmt.Errorf("failed to open %s. Error: %w", url, err)
+		}
+		content = file
+	}
+	defer content.Close()
+
+	file, err := os.Create(archivePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(file, hash), content); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		return "", fmt.Errorf("failed to download %s. Error: %w", url, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// archiveChecksums Get the checksum of each file inside a directory, or the target of symbolic links
+func archiveChecksums(dir string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relPath, _ := filepath.Rel(dir, path)
+		relPath = filepath.ToSlash(relPath)
+		if relPath == ArchiveStampFile {
+			return nil
+		}
+		if entry.Type()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			files[relPath] = "link:" + target
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		checksum := sha256.Sum256(content)
+		files[relPath] = hex.EncodeToString(checksum[:])
+		return nil
+	})
+	return files, err
+}
+
+// readArchiveStamp Read the stamp of an extracted archive
+func readArchiveStamp(path string) (archiveStamp, error) {
+	var stamp archiveStamp
+	data, err := os.ReadFile(filepath.Join(path, ArchiveStampFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return stamp, fmt.Errorf("%s exists and was not extracted from an archive. Use force to overwrite it", path)
+	} else if err != nil {
+		return stamp, err
+	}
+	if err := yaml.Unmarshal(data, &stamp); err != nil {
+		return stamp, fmt.Errorf("invalid archive stamp in %s. Error: %w", path, err)
+	}
+	return stamp, nil
+}
+
+// archiveDrift Get the files of an extracted archive that were modified, added or removed
+func archiveDrift(path string, stamp archiveStamp) []string {
+	files, err := archiveChecksums(path)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	var drift []string
+	for name, checksum := range files {
+		expected, ok := stamp.Files[name]
+		if !ok {
+			drift = append(drift, "added: "+name)
+		} else if checksum != expected {
+			drift = append(drift, "modified: "+name)
+		}
+	}
+	for name := range stamp.Files {
+		if _, ok := files[name]; !ok {
+			drift = append(drift, "removed: "+name)
+		}
+	}
+	sort.Strings(drift)
+	return drift
+}
+
+// ArchiveInfo Get the URL a tar or zip repository was extracted from and its files that were
+// modified, added or removed since
+func ArchiveInfo(path string) (string, []string, error) {
+	stamp, err := readArchiveStamp(path)
+	if err != nil {
+		return "", nil, err
+	}
+	return stamp.URL, archiveDrift(path, stamp), nil
+}
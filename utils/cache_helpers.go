@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CacheEntry Bare mirror of a remote repository kept in the cache directory
+type CacheEntry struct {
+	Path     string    `json:"path"`
+	URL      string    `json:"url"`
+	Size     int64     `json:"size"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// lockRetryInterval Time waited before trying again to take a lock held by someone else
+const lockRetryInterval = 100 * time.Millisecond
+
+// CacheMirrorPath Get the path of the mirror of a URL inside the cache directory
+func CacheMirrorPath(cacheDir string, url string) string {
+	hash := sha256.Sum256([]byte(url))
+	name := strings.TrimSuffix(filepath.Base(strings.TrimRight(url, "/")), ".git")
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		name = "repo"
+	}
+	return filepath.Join(cacheDir, fmt.Sprintf("%s-%s.git", name, hex.EncodeToString(hash[:])[:16]))
+}
+
+// LockCacheMirror Lock a mirror of the cache directory, also against other processes. Updates and
+// removals take an exclusive lock, while clones referencing the mirror share it
+func LockCacheMirror(ctx context.Context, mirrorPath string, exclusive bool) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(mirrorPath), 0755); err != nil {
+		return nil, err
+	}
+	unlock, err := lockFile(ctx, mirrorPath+".lock", exclusive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock cache %s. Error: %w", mirrorPath, err)
+	}
+	return unlock, nil
+}
+
+// UpdateCacheMirror Create or fetch the mirror of a URL and get its path. An existing mirror is
+// not fetched when it already has the given version, a commit hash or a tag
+func UpdateCacheMirror(ctx context.Context, cacheDir string, url string, version string, enablePrompt bool) (string, error) {
+	mirrorPath := CacheMirrorPath(cacheDir, url)
+	unlock, err := LockCacheMirror(ctx, mirrorPath, true)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	var envConfig []string
+	if enablePrompt {
+		envConfig = []string{"GIT_TERMINAL_PROMPT=1"}
+	} else {
+		envConfig = []string{"GIT_TERMINAL_PROMPT=0"}
+	}
+
+	if isBareRepository(mirrorPath) {
+		if !mirrorHasVersion(ctx, mirrorPath, version) {
+			if _, err := RunGitCmdContext(ctx, mirrorPath, "fetch", envConfig, "--prune", "origin"); err != nil {
+				return mirrorPath, fmt.Errorf("failed to update cache of %s. Error: %w", url, err)
+			}
+		}
+	} else {
+		if err := os.MkdirAll(cacheDir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create cache directory %s. Error: %w", cacheDir, err)
+		}
+		// Clone next to the final path so an interrupted clone is never used as a mirror
+		tempPath, err := os.MkdirTemp(cacheDir, ".tmp-")
+		if err != nil {
+			return "", fmt.Errorf("failed to create cache directory %s. Error: %w", cacheDir, err)
+		}
+		defer os.RemoveAll(tempPath)
+		if _, err := RunGitCmdContext(ctx, ".", "clone", envConfig, "--mirror", url, tempPath); err != nil {
+			return "", fmt.Errorf("failed to cache %s. Error: %w", url, err)
+		}
+		os.RemoveAll(mirrorPath)
+		if err := os.Rename(tempPath, mirrorPath); err != nil {
+			return "", fmt.Errorf("failed to cache %s. Error: %w", url, err)
+		}
+	}
+	now := time.Now()
+	os.Chtimes(mirrorPath, now, now)
+	return mirrorPath, nil
+}
+
+// mirrorHasVersion Check if a mirror has a commit or a tag. Branches may have moved on the
+// remote, so they are never considered available
+func mirrorHasVersion(ctx context.Context, mirrorPath string, version string) bool {
+	var err error
+	switch {
+	case version == "":
+		return false
+	case IsValidSha(version):
+		_, err = RunGitCmdContext(ctx, mirrorPath, "cat-file", nil, "-e", version+"^{commit}")
+	default:
+		_, err = RunGitCmdContext(ctx, mirrorPath, "show-ref", nil, "--verify", "--quiet", "refs/tags/"+version)
+	}
+	return err == nil
+}
+
+// ListCacheEntries Get the mirrors kept in the cache directory sorted by path
+func ListCacheEntries(ctx context.Context, cacheDir string) ([]CacheEntry, error) {
+	dirEntries, err := os.ReadDir(cacheDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var entries []CacheEntry
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() || !strings.HasSuffix(dirEntry.Name(), ".git") {
+			continue
+		}
+		mirrorPath := filepath.Join(cacheDir, dirEntry.Name())
+		entry := CacheEntry{Path: mirrorPath}
+		if info, err := dirEntry.Info(); err == nil {
+			entry.LastUsed = info.ModTime()
+		}
+		entry.URL, _ = RunGitCmdContext(ctx, mirrorPath, "config", nil, "--get", "remote.origin.url")
+		entry.URL = strings.TrimSpace(entry.URL)
+		entry.Size = directorySize(mirrorPath)
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+// PruneCache Remove the mirrors not used since the given time, the ones without URL
+// and any leftover of an interrupted clone. Get the removed paths
+func PruneCache(ctx context.Context, cacheDir string, unusedSince time.Time) ([]string, error) {
+	entries, err := ListCacheEntries(ctx, cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, entry := range entries {
+		if entry.URL != "" && !entry.LastUsed.Before(unusedSince) {
+			continue
+		}
+		unlock, err := LockCacheMirror(ctx, entry.Path, true)
+		if err != nil {
+			return removed, err
+		}
+		err = os.RemoveAll(entry.Path)
+		unlock()
+		if err != nil {
+			return removed, err
+		}
+		removed = append(removed, entry.Path)
+	}
+	tempPaths, _ := filepath.Glob(filepath.Join(cacheDir, ".tmp-*"))
+	for _, tempPath := range tempPaths {
+		if info, err := os.Stat(tempPath); err == nil && info.ModTime().Before(unusedSince) {
+			os.RemoveAll(tempPath)
+			removed = append(removed, tempPath)
+		}
+	}
+	return removed, nil
+}
+
+// VerifyCacheMirror Check the connectivity and validity of the objects of a mirror
+func VerifyCacheMirror(ctx context.Context, mirrorPath string) (string, error) {
+	output, err := RunGitCmdContext(ctx, mirrorPath, "fsck", nil, "--connectivity-only", "--no-dangling")
+	if err != nil {
+		return "", fmt.Errorf("corrupted cache %s. Error: %w", mirrorPath, err)
+	}
+	return output, nil
+}
+
+// isBareRepository Check if a directory is a bare git repository
+func isBareRepository(dir string) bool {
+	_, headErr := os.Stat(filepath.Join(dir, "HEAD"))
+	_, objectsErr := os.Stat(filepath.Join(dir, "objects"))
+	return headErr == nil && objectsErr == nil
+}
+
+// directorySize Get the size of all the files inside a directory
+func directorySize(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			if info, err := entry.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
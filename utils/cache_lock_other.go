@@ -0,0 +1,31 @@
+//go:build !unix
+
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Locks of each file within the process, as file locks are only used on unix systems
+var fileLocks sync.Map
+
+// lockFile Hold a shared or exclusive lock on a file until the returned function is called.
+// The lock is only held within the process. Waits for the lock until the context is done
+func lockFile(ctx context.Context, path string, exclusive bool) (func(), error) {
+	value, _ := fileLocks.LoadOrStore(path, &sync.RWMutex{})
+	lock := value.(*sync.RWMutex)
+	tryLock, unlock := lock.TryRLock, lock.RUnlock
+	if exclusive {
+		tryLock, unlock = lock.TryLock, lock.Unlock
+	}
+	for !tryLock() {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+	return unlock, nil
+}
@@ -0,0 +1,217 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// GitDiffOptions Settings used to get the changes of a repository
+type GitDiffOptions struct {
+	// Staged Show the changes added to the index instead of the unstaged ones
+	Staged bool
+	// Stat Only show a summary of the changed files
+	Stat bool
+	// NameOnly Only show the names of the changed files
+	NameOnly bool
+	// Prefix Path prepended to every file of the diff, e.g. the path of the repository in the workspace
+	Prefix string
+	// Patch Produce a patch that can be applied with git apply, including binary changes
+	Patch bool
+}
+
+// GitDiff Get the uncommitted changes of a given git repository. Unless only the staged
+// changes are requested, untracked files that are not ignored are shown as new files
+func GitDiff(ctx context.Context, path string, opts GitDiffOptions) (string, error) {
+	var envConfig []string
+	if !opts.Staged {
+		indexFile, err := untrackedIndex(ctx, path)
+		if err != nil {
+			return "", fmt.Errorf("failed to get untracked files of %s. Error: %w", path, err)
+		}
+		if indexFile != "" {
+			defer os.Remove(indexFile)
+			envConfig = []string{"GIT_INDEX_FILE=" + indexFile}
+		}
+	}
+
+	diffArgs := []string{"--no-ext-diff"}
+	if opts.Staged {
+		diffArgs = append(diffArgs, "--staged")
+	}
+	switch {
+	case opts.Stat:
+		diffArgs = append(diffArgs, "--stat")
+	case opts.NameOnly:
+		diffArgs = append(diffArgs, "--name-only")
+	}
+	if opts.Patch {
+		diffArgs = append(diffArgs, "--no-color", "--binary")
+	}
+	if prefix := strings.Trim(opts.Prefix, "/"); prefix != "" && prefix != "." {
+		diffArgs = append(diffArgs, "--src-prefix=a/"+prefix+"/", "--dst-prefix=b/"+prefix+"/")
+	}
+	output, err := RunGitCmdContext(ctx, path, "diff", envConfig, diffArgs...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get Git diff of %s. Error: %w", path, err)
+	}
+	return output, nil
+}
+
+// untrackedIndex Create a copy of the index of a repository where its untracked files are
+// marked as intent to add, so that git diff shows them as new files. The repository index is
+// left unchanged. Empty when there are no untracked files
+func untrackedIndex(ctx context.Context, path string) (string, error) {
+	untracked, err := RunGitCmdContext(ctx, path, "ls-files", nil, "--others", "--exclude-standard", "-z")
+	if err != nil || untracked == "" {
+		return "", err
+	}
+	indexPath, err := RunGitCmdContext(ctx, path, "rev-parse", nil, "--path-format=absolute", "--git-path", "index")
+	if err != nil {
+		return "", err
+	}
+	index, err := os.ReadFile(strings.TrimSpace(indexPath))
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	indexFile, err := os.CreateTemp("", "rv-index-*")
+	if err != nil {
+		return "", err
+	}
+	pathspecFile, err := os.CreateTemp("", "rv-untracked-*")
+	if err != nil {
+		indexFile.Close()
+		os.Remove(indexFile.Name())
+		return "", err
+	}
+	defer os.Remove(pathspecFile.Name())
+	_, err = indexFile.Write(index)
+	if closeErr := indexFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		_, err = pathspecFile.WriteString(untracked)
+	}
+	if closeErr := pathspecFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		// git refuses an empty file as index, while a missing one is created
+		if len(index) == 0 {
+			os.Remove(indexFile.Name())
+		}
+		_, err = RunGitCmdContext(ctx, path, "add", []string{"GIT_INDEX_FILE=" + indexFile.Name()},
+			"--intent-to-add", "--pathspec-from-file="+pathspecFile.Name(), "--pathspec-file-nul")
+	}
+	if err != nil {
+		os.Remove(indexFile.Name())
+		return "", err
+	}
+	return indexFile.Name(), nil
+}
+
+// GitApply Apply a patch to a given git repository removing the given number of leading
+// path components from the files of the patch. Only checks that the patch applies when check is set
+func GitApply(ctx context.Context, path string, patch string, strip int, check bool) (string, error) {
+	patchFile, err := os.CreateTemp("", "rv-*.patch")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(patchFile.Name())
+	if _, err := patchFile.WriteString(patch); err != nil {
+		patchFile.Close()
+		return "", err
+	}
+	if err := patchFile.Close(); err != nil {
+		return "", err
+	}
+
+	applyArgs := []string{fmt.Sprintf("-p%d", strip)}
+	if check {
+		applyArgs = append(applyArgs, "--check")
+	}
+	applyArgs = append(applyArgs, patchFile.Name())
+	output, err := RunGitCmdContext(ctx, path, "apply", nil, applyArgs...)
+	if err != nil {
+		return "", fmt.Errorf("failed to apply patch to %s. Error: %w", path, err)
+	}
+	return output, nil
+}
+
+// PatchFiles Number of files changed by a patch
+func PatchFiles(patch string) int {
+	return len(splitPatchFiles(patch))
+}
+
+// SplitCombinedPatch Group the files of a patch whose paths start with the path of a repository,
+// as produced by GitDiff with a prefix. Files are assigned to the repository with the longest
+// matching path, where "." matches every file
+func SplitCombinedPatch(patch string, repos []string) (map[string]string, error) {
+	patches := make(map[string]string)
+	for _, file := range splitPatchFiles(patch) {
+		filePath := patchFilePath(file)
+		repo, found := "", false
+		for _, candidate := range repos {
+			candidate = strings.Trim(candidate, "/")
+			if candidate != "." && !strings.HasPrefix(filePath, candidate+"/") {
+				continue
+			}
+			if !found || repo == "." || len(candidate) > len(repo) {
+				repo, found = candidate, true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no repository found for %s", filePath)
+		}
+		patches[repo] += file
+	}
+	return patches, nil
+}
+
+// PatchStripComponents Number of leading path components to remove from the files of a
+// combined patch to get their path inside the repository
+func PatchStripComponents(repo string) int {
+	repo = strings.Trim(repo, "/")
+	if repo == "." || repo == "" {
+		return 1
+	}
+	return 1 + len(strings.Split(repo, "/"))
+}
+
+// splitPatchFiles Split a patch into the changes of each file, ignoring any text before the first file
+func splitPatchFiles(patch string) []string {
+	var files []string
+	var current strings.Builder
+	for _, line := range strings.SplitAfter(patch, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			if current.Len() > 0 {
+				files = append(files, current.String())
+			}
+			current.Reset()
+		} else if current.Len() == 0 {
+			continue
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		files = append(files, current.String())
+	}
+	return files
+}
+
+// patchFilePath Get the path of the file changed by a single file patch, without the a/ prefix
+func patchFilePath(file string) string {
+	header, _, _ := strings.Cut(file, "\n")
+	header = strings.TrimPrefix(header, "diff --git ")
+	header = strings.TrimPrefix(header, "\"")
+	header = strings.TrimPrefix(header, "a/")
+	// The header holds both paths separated by " b/", which is only ambiguous for unusual names
+	if index := strings.Index(header, " b/"); index >= 0 {
+		header = header[:index]
+	} else if index := strings.Index(header, "\" \"b/"); index >= 0 {
+		header = header[:index]
+	}
+	return strings.TrimSuffix(header, "\"")
+}
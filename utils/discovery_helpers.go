@@ -0,0 +1,240 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DiscoveryOptions Settings used to search repositories in a directory tree
+type DiscoveryOptions struct {
+	// MaxDepth Maximum number of directories below the root searched. Zero means no limit
+	MaxDepth int
+	// FollowSymlinks Also search the directories symbolic links point to
+	FollowSymlinks bool
+	// Ignore Patterns in gitignore syntax, relative to the root, of the paths not searched.
+	// They apply along with the patterns of the .rvignore files found
+	Ignore []string
+	// IncludeSubmodules Also find the checkouts of the submodules of the repositories found.
+	// They are left out by default, as their parent repository manages them
+	IncludeSubmodules bool
+}
+
+// Discovery Repositories found searching a directory tree
+type Discovery struct {
+	// Repositories Directories for which the repository check is true
+	Repositories []string
+	// Bare Bare git repositories found, which are not searched nor used as repositories
+	Bare []string
+}
+
+// discoveryWorkers Number of directories read concurrently while searching repositories
+const discoveryWorkers = 16
+
+// skippedDirectories Directories never searched: the internals of the supported version control
+// systems and the heavy build, install and log trees of colcon workspaces
+var skippedDirectories = map[string]bool{
+	".git":    true,
+	".hg":     true,
+	".svn":    true,
+	"build":   true,
+	"install": true,
+	"log":     true,
+}
+
+// repositoryWalker Concurrent search of the directories matching a repository check
+type repositoryWalker struct {
+	opts         DiscoveryOptions
+	isRepository func(string) bool
+	semaphore    chan struct{}
+	waitGroup    sync.WaitGroup
+	mutex        sync.Mutex
+	repos        []string
+	bare         []string
+	err          error
+	visited      map[string]bool
+	// collectIgnored Search the ignored directories and collect their repositories only
+	collectIgnored bool
+}
+
+// WalkRepositories Get the directories at the given root, the root included, for which
+// isRepository is true. Nested repositories are searched too, while bare repositories, ignored
+// paths and the directories in skippedDirectories are not, unless they are repositories
+// themselves. Paths are sorted as a depth-first walk would find them
+func WalkRepositories(root string, opts DiscoveryOptions, isRepository func(string) bool) ([]string, error) {
+	discovery, err := DiscoverRepositories(root, opts, isRepository)
+	return discovery.Repositories, err
+}
+
+// DiscoverRepositories Get the repositories found by WalkRepositories along with the bare
+// repositories it leaves out
+func DiscoverRepositories(root string, opts DiscoveryOptions, isRepository func(string) bool) (Discovery, error) {
+	return walkRepositories(root, opts, isRepository, false)
+}
+
+// WalkIgnoredRepositories Get the repositories at the given root that WalkRepositories leaves
+// out because they are ignored by the given patterns or by .rvignore files
+func WalkIgnoredRepositories(root string, opts DiscoveryOptions, isRepository func(string) bool) ([]string, error) {
+	discovery, err := walkRepositories(root, opts, isRepository, true)
+	return discovery.Repositories, err
+}
+
+// walkRepositories Search the repositories at the given root, either the searched or the ignored ones
+func walkRepositories(root string, opts DiscoveryOptions, isRepository func(string) bool, collectIgnored bool) (Discovery, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return Discovery{}, err
+	}
+	if !info.IsDir() {
+		return Discovery{}, nil
+	}
+	ignore, err := NewIgnoreMatcher(root, opts.Ignore)
+	if err != nil {
+		return Discovery{}, err
+	}
+	walker := &repositoryWalker{
+		opts:           opts,
+		isRepository:   isRepository,
+		semaphore:      make(chan struct{}, discoveryWorkers),
+		visited:        make(map[string]bool),
+		collectIgnored: collectIgnored,
+	}
+	walker.markVisited(root)
+	walker.waitGroup.Add(1)
+	go walker.walk(root, 0, IgnoreStack{ignore}, false)
+	walker.waitGroup.Wait()
+
+	sortWalkOrder(walker.repos)
+	sortWalkOrder(walker.bare)
+	repos := walker.repos
+	if !opts.IncludeSubmodules {
+		repos = withoutSubmodules(repos)
+	}
+	return Discovery{Repositories: repos, Bare: walker.bare}, walker.err
+}
+
+// sortWalkOrder Sort paths as a depth-first walk would find them
+func sortWalkOrder(paths []string) {
+	slices.SortFunc(paths, func(a, b string) int {
+		// Compare path components so that a/b comes before a-b, as filepath.Walk does
+		return strings.Compare(strings.ReplaceAll(a, string(filepath.Separator), "\x00"),
+			strings.ReplaceAll(b, string(filepath.Separator), "\x00"))
+	})
+}
+
+// withoutSubmodules Remove the submodule checkouts inside other repositories of a sorted list
+func withoutSubmodules(repos []string) []string {
+	var kept []string
+	for _, repo := range repos {
+		if IsGitSubmodule(repo) && slices.ContainsFunc(kept, func(parent string) bool { return isWithinDir(parent, repo) }) {
+			continue
+		}
+		kept = append(kept, repo)
+	}
+	return kept
+}
+
+// walk Search a directory and start searching its subdirectories
+func (w *repositoryWalker) walk(dir string, depth int, ignoreStack IgnoreStack, ignored bool) {
+	defer w.waitGroup.Done()
+	w.semaphore <- struct{}{}
+	isRepo := w.isRepository(dir)
+	entries, err := os.ReadDir(dir)
+	var ignoreFile *IgnoreMatcher
+	if err == nil && slices.ContainsFunc(entries, func(entry os.DirEntry) bool { return entry.Name() == IgnoreFileName }) {
+		ignoreFile, err = ReadIgnoreFile(dir)
+	}
+	<-w.semaphore
+
+	isBare := err == nil && !isRepo && hasBareRepositoryEntries(entries)
+	w.mutex.Lock()
+	if isRepo && ignored == w.collectIgnored {
+		w.repos = append(w.repos, dir)
+	}
+	if isBare && ignored == w.collectIgnored {
+		w.bare = append(w.bare, dir)
+	}
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	w.mutex.Unlock()
+	if err != nil || isBare {
+		return
+	}
+	if w.opts.MaxDepth > 0 && depth >= w.opts.MaxDepth {
+		return
+	}
+
+	ignoreStack = ignoreStack.Push(ignoreFile)
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !w.isSearched(path, entry) {
+			continue
+		}
+		// Everything inside an ignored directory is ignored too
+		isIgnored := ignored || ignoreStack.IsIgnored(path, true)
+		if isIgnored && !w.collectIgnored {
+			continue
+		}
+		w.waitGroup.Add(1)
+		go w.walk(path, depth+1, ignoreStack, isIgnored)
+	}
+}
+
+// isSearched Check if a directory entry is a directory to search
+func (w *repositoryWalker) isSearched(path string, entry os.DirEntry) bool {
+	if entry.Type()&os.ModeSymlink != 0 {
+		if !w.opts.FollowSymlinks {
+			return false
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	} else if !entry.IsDir() {
+		return false
+	}
+	if skippedDirectories[entry.Name()] && !w.isRepository(path) {
+		return false
+	}
+	return !w.opts.FollowSymlinks || w.markVisited(path)
+}
+
+// markVisited Record the directory a path resolves to. Returns false when it was already
+// visited, which prevents symbolic link loops from being searched forever
+func (w *repositoryWalker) markVisited(path string) bool {
+	if !w.opts.FollowSymlinks {
+		return true
+	}
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.visited[realPath] {
+		return false
+	}
+	w.visited[realPath] = true
+	return true
+}
+
+// hasBareRepositoryEntries Check if the entries of a directory are the ones of a bare git repository
+func hasBareRepositoryEntries(entries []os.DirEntry) bool {
+	found := 0
+	for _, entry := range entries {
+		switch entry.Name() {
+		case "HEAD":
+			if !entry.IsDir() {
+				found++
+			}
+		case "objects", "refs":
+			if entry.IsDir() {
+				found++
+			}
+		}
+	}
+	return found == 3
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"sync"
+)
+
+// CommandOutput Output and exit code of a command
+type CommandOutput struct {
+	Stdout string
+	Stderr string
+	// Combined Stdout and stderr in the order they were written
+	Combined string
+	// ExitCode Exit code of the command, or -1 if it could not be started
+	ExitCode int
+}
+
+// lockedWriter Writer that can be shared by the stdout and stderr of a command
+type lockedWriter struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+func (w *lockedWriter) Write(data []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.writer.Write(data)
+}
+
+// RunCommandContext Execute a command in a given path capturing stdout and stderr separately.
+// The command is killed once the context is done
+func RunCommandContext(ctx context.Context, path string, name string, args ...string) (CommandOutput, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = path
+	cmd.WaitDelay = gitWaitDelay
+
+	var stdout, stderr, combined bytes.Buffer
+	combinedWriter := &lockedWriter{writer: &combined}
+	cmd.Stdout = io.MultiWriter(&stdout, combinedWriter)
+	cmd.Stderr = io.MultiWriter(&stderr, combinedWriter)
+	err := cmd.Run()
+
+	output := CommandOutput{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		Combined: combined.String(),
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		output.ExitCode = -1
+		return output, ctxErr
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		output.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		output.ExitCode = -1
+	}
+	return output, err
+}
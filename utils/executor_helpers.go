@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Supported orders to report the results of an Executor
+const (
+	OrderPath       = "path"
+	OrderCompletion = "completion"
+)
+
+// DefaultWorkers Number of concurrent workers used when none is given
+const DefaultWorkers = 8
+
+// RepoFunc Operation executed on a single repository. Returning nil omits the repository from the results
+type RepoFunc func(ctx context.Context, path string) *RepoResult
+
+// Executor Run an operation concurrently over multiple repositories
+type Executor struct {
+	// Workers Number of concurrent workers
+	Workers int
+	// Order Order used to report the results. Either OrderPath or OrderCompletion
+	Order string
+	// RepoTimeout Maximum duration of the operation on a single repository. Zero means no limit
+	RepoTimeout time.Duration
+	// OnStart Called when the operation starts on a repository. Can be called concurrently
+	OnStart func(path string)
+	// OnResult Called with each result following the selected order. Calls never overlap
+	OnResult func(result RepoResult)
+	// OnProgress Called with the progress reported by git clone, fetch and pull commands
+	OnProgress func(path string, progress GitProgress)
+}
+
+// ValidateOrder Check if the given order is supported
+func ValidateOrder(order string) error {
+	switch order {
+	case OrderPath, OrderCompletion:
+		return nil
+	}
+	return fmt.Errorf("invalid order '%s'. Expected one of: %s, %s", order, OrderPath, OrderCompletion)
+}
+
+// Run Execute the operation on each of the given paths and collect the results.
+// Once the context is done, the remaining paths are reported with the context error
+// without running the operation.
+func (e *Executor) Run(ctx context.Context, paths []string, operation string, run RepoFunc) []RepoResult {
+	numWorkers := e.Workers
+	if numWorkers <= 0 {
+		numWorkers = DefaultWorkers
+	}
+
+	jobPaths := append([]string{}, paths...)
+	if e.Order != OrderCompletion {
+		sort.Strings(jobPaths)
+	}
+
+	// Results are kept until all the previous paths have been reported
+	pending := make([]*RepoResult, len(jobPaths))
+	finished := make([]bool, len(jobPaths))
+	nextToReport := 0
+	var results []RepoResult
+	var resultsMutex sync.Mutex
+
+	report := func(result *RepoResult) {
+		if result == nil {
+			return
+		}
+		results = append(results, *result)
+		if e.OnResult != nil {
+			e.OnResult(*result)
+		}
+	}
+
+	jobs := make(chan int, len(jobPaths))
+	var wg sync.WaitGroup
+	for range numWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				result := e.runRepo(ctx, jobPaths[idx], operation, run)
+
+				resultsMutex.Lock()
+				if e.Order == OrderCompletion {
+					report(result)
+				} else {
+					pending[idx] = result
+					finished[idx] = true
+					for nextToReport < len(jobPaths) && finished[nextToReport] {
+						report(pending[nextToReport])
+						pending[nextToReport] = nil
+						nextToReport++
+					}
+				}
+				resultsMutex.Unlock()
+			}
+		}()
+	}
+	for idx := range jobPaths {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// runRepo Run the operation on a single repository applying the repository timeout
+func (e *Executor) runRepo(ctx context.Context, path string, operation string, run RepoFunc) *RepoResult {
+	if err := ctx.Err(); err != nil {
+		return &RepoResult{Path: path, Operation: operation, Err: err}
+	}
+	if e.OnStart != nil {
+		e.OnStart(path)
+	}
+
+	repoCtx := ctx
+	if e.OnProgress != nil {
+		repoCtx = WithGitProgress(repoCtx, func(progress GitProgress) {
+			e.OnProgress(path, progress)
+		})
+	}
+	if e.RepoTimeout > 0 {
+		var cancel context.CancelFunc
+		repoCtx, cancel = context.WithTimeout(repoCtx, e.RepoTimeout)
+		defer cancel()
+	}
+	result := run(repoCtx, path)
+	if result != nil && result.Err != nil && ctx.Err() == nil && errors.Is(repoCtx.Err(), context.DeadlineExceeded) {
+		result.Err = fmt.Errorf("timed out after %s: %w", e.RepoTimeout, result.Err)
+	}
+	return result
+}
@@ -3,6 +3,9 @@
 package utils
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -10,8 +13,12 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// gitWaitDelay Time given to git to release its output after being killed
+const gitWaitDelay = 2 * time.Second
+
 // Create constant Error messages
 const (
 	SuccessfullClone = iota
@@ -22,25 +29,27 @@ const (
 
 // IsGitRepository checks if a directory is a git repository
 func IsGitRepository(dir string) bool {
-	gitDir := filepath.Join(dir, ".git")
-	_, err := os.Stat(gitDir)
-	return err == nil
+	gitPath := filepath.Join(dir, ".git")
+	info, err := os.Stat(gitPath)
+	if err != nil {
+		return false
+	}
+	if info.IsDir() {
+		return true
+	}
+	// Worktrees and submodules use a .git file pointing at their git directory
+	content, err := os.ReadFile(gitPath)
+	return err == nil && strings.HasPrefix(string(content), "gitdir:")
+}
+
+// ListGitRepositories Get a slice of all the found git repositories at the given root
+func ListGitRepositories(root string) ([]string, error) {
+	return WalkRepositories(root, DiscoveryOptions{}, IsGitRepository)
 }
 
 // FindGitRepositories Get a slice of all the found git repositories at the given root
 func FindGitRepositories(root string) []string {
-	var gitRepos []string
-
-	// Use an anonymous function to  check each file found relative to the given root
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err // Return any error encountered during walking
-		}
-		if info.IsDir() && IsGitRepository(path) {
-			gitRepos = append(gitRepos, path)
-		}
-		return nil // Continue walking
-	})
+	gitRepos, err := ListGitRepositories(root)
 	if err != nil {
 		PrintErrorMsg(fmt.Sprintf("Error: %s", err))
 	}
@@ -49,96 +58,502 @@ func FindGitRepositories(root string) []string {
 
 // RunGitCmd Helper method to execute a git command
 func RunGitCmd(path string, gitCmd string, envConfig []string, args ...string) (string, error) {
-	cmdArgs := append([]string{"-c", "color.ui=always", gitCmd}, args...)
-	cmd := exec.Command("git", cmdArgs...)
+	return RunGitCmdContext(context.Background(), path, gitCmd, envConfig, args...)
+}
+
+// RunGitCmdContext Helper method to execute a git command that is killed once the context is done
+func RunGitCmdContext(ctx context.Context, path string, gitCmd string, envConfig []string, args ...string) (string, error) {
+	return RunGitCmdInput(ctx, path, gitCmd, envConfig, "", args...)
+}
+
+// RunGitCmdInput Helper method to execute a git command reading the given input from stdin,
+// e.g. lists of objects too long to be passed as arguments
+func RunGitCmdInput(ctx context.Context, path string, gitCmd string, envConfig []string, input string, args ...string) (string, error) {
+	cmdArgs := []string{"-c", "color.ui=" + gitColorMode(), gitCmd}
+	report := gitProgressReporter(ctx, gitCmd)
+	if report != nil {
+		cmdArgs = append(cmdArgs, "--progress")
+	}
+	cmdArgs = append(cmdArgs, args...)
+	cmd := exec.CommandContext(ctx, "git", cmdArgs...)
 	cmd.Env = append(os.Environ(), envConfig...)
 	cmd.Dir = path
+	cmd.WaitDelay = gitWaitDelay
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
 
-	output, err := cmd.CombinedOutput()
+	var output []byte
+	var err error
+	if report != nil {
+		// Progress lines are reported instead of being part of the output
+		var buffer bytes.Buffer
+		writer := &progressWriter{output: &buffer, report: report}
+		cmd.Stdout = writer
+		cmd.Stderr = writer
+		err = cmd.Run()
+		writer.Flush()
+		output = buffer.Bytes()
+	} else {
+		output, err = cmd.CombinedOutput()
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return "", ctxErr
+	}
 	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
 		return "", err
 	}
 	return string(output), nil
 }
 
-// GetGitStatus Execute git status in a given path
-func GetGitStatus(path string, plainStatus bool) string {
+// GitStatus Execute git status in a given path
+func GitStatus(ctx context.Context, path string, plainStatus bool) (string, error) {
 	var statusArgs []string
 	if plainStatus {
 		statusArgs = []string{"-sb"}
 	}
-	output, err := RunGitCmd(path, "status", nil, statusArgs...)
+	output, err := RunGitCmdContext(ctx, path, "status", nil, statusArgs...)
 	if err != nil {
-		PrintErrorMsg(fmt.Sprintf("Failed to check Git status of %s. Error: %s", path, err))
+		return "", fmt.Errorf("failed to check Git status of %s. Error: %w", path, err)
+	}
+	return output, nil
+}
+
+// GetGitStatus Execute git status in a given path
+func GetGitStatus(path string, plainStatus bool) string {
+	output, err := GitStatus(context.Background(), path, plainStatus)
+	if err != nil {
+		PrintErrorMsg(err.Error())
 	}
 	return output
 }
 
-// GetGitBranch Get current git branch in a given path
-func GetGitBranch(path string) string {
-	output, err := RunGitCmd(path, "branch", nil, "--show-current")
+// GitBranch Get current git branch or tag in a given path
+func GitBranch(ctx context.Context, path string) (string, error) {
+	output, err := RunGitCmdContext(ctx, path, "branch", nil, "--show-current")
 	if err != nil {
-		PrintErrorMsg(fmt.Sprintf("Failed to get current Git branch of %s. Error: %s", path, err))
+		return "", fmt.Errorf("failed to get current Git branch of %s. Error: %w", path, err)
 	}
 	if output != "" {
-		return strings.TrimSpace(output)
+		return strings.TrimSpace(output), nil
 	}
 	checkTagArgs := []string{"--points-at", "HEAD"}
-	output, err = RunGitCmd(path, "tag", nil, checkTagArgs...)
+	output, err = RunGitCmdContext(ctx, path, "tag", nil, checkTagArgs...)
 	if err != nil {
-		PrintErrorMsg(fmt.Sprintf("Failed to get current Git branch of %s. Error: %s", path, err))
+		return "", fmt.Errorf("failed to get current Git branch of %s. Error: %w", path, err)
 	}
-	return strings.TrimSpace(output)
+	return strings.TrimSpace(output), nil
 }
 
-func GetGitCommitSha(path string) string {
+// GetGitBranch Get current git branch in a given path
+func GetGitBranch(path string) string {
+	output, err := GitBranch(context.Background(), path)
+	if err != nil {
+		PrintErrorMsg(err.Error())
+	}
+	return output
+}
+
+// GitCommitSha Get the commit SHA of HEAD in a given path
+func GitCommitSha(ctx context.Context, path string) (string, error) {
 	cmdArgs := []string{"--verify", "HEAD"}
-	output, err := RunGitCmd(path, "rev-parse", nil, cmdArgs...)
+	output, err := RunGitCmdContext(ctx, path, "rev-parse", nil, cmdArgs...)
 	if err != nil {
-		PrintErrorMsg(fmt.Sprintf("Failed to get current Git commit of %s. Error: %s", path, err))
+		return "", fmt.Errorf("failed to get current Git commit of %s. Error: %w", path, err)
 	}
-	return strings.TrimSpace(output)
+	return strings.TrimSpace(output), nil
 }
 
-func GetGitRemoteURL(path string) string {
+func GetGitCommitSha(path string) string {
+	output, err := GitCommitSha(context.Background(), path)
+	if err != nil {
+		PrintErrorMsg(err.Error())
+	}
+	return output
+}
+
+// GitRemoteURL Get the URL of the origin remote in a given path
+func GitRemoteURL(ctx context.Context, path string) (string, error) {
 	cmdArgs := []string{"get-url", "origin"}
-	output, err := RunGitCmd(path, "remote", nil, cmdArgs...)
+	output, err := RunGitCmdContext(ctx, path, "remote", nil, cmdArgs...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get URL for the origin remote of %s. Error: %w", path, err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// GitChangedSince Check if the working tree of a given path differs from a commit, branch or tag
+func GitChangedSince(ctx context.Context, path string, ref string) (bool, error) {
+	_, err := RunGitCmdContext(ctx, path, "diff", nil, "--quiet", ref, "--")
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to compare %s with %s. Error: %w", path, ref, err)
+	}
+	return false, nil
+}
+
+// GitTagsConfigKey Git configuration entry recording the tags of an imported repository
+const GitTagsConfigKey = "rv.tags"
+
+// GitTags Get the tags recorded for a given git repository
+func GitTags(ctx context.Context, path string) ([]string, error) {
+	output, err := RunGitCmdContext(ctx, path, "config", nil, "--get", GitTagsConfigKey)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags of %s. Error: %w", path, err)
+	}
+	var tags []string
+	for _, tag := range strings.Split(strings.TrimSpace(output), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// GitSetTags Record the tags of a given git repository. Empty tags remove any recorded ones
+func GitSetTags(ctx context.Context, path string, tags []string) error {
+	var err error
+	if len(tags) == 0 {
+		_, err = RunGitCmdContext(ctx, path, "config", nil, "--unset-all", GitTagsConfigKey)
+		var exitErr *exec.ExitError
+		// Exit code 5 means there were no tags to remove
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 5 {
+			err = nil
+		}
+	} else {
+		_, err = RunGitCmdContext(ctx, path, "config", nil, GitTagsConfigKey, strings.Join(tags, ","))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set tags of %s. Error: %w", path, err)
+	}
+	return nil
+}
+
+// GitRemotes Get the URL of every remote of a given git repository, keyed by remote name
+func GitRemotes(ctx context.Context, path string) (map[string]string, error) {
+	output, err := RunGitCmdContext(ctx, path, "config", nil, "--get-regexp", `^remote\..*\.url$`)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get remotes of %s. Error: %w", path, err)
+	}
+	remotes := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		key, url, found := strings.Cut(line, " ")
+		if !found {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(key, "remote."), ".url")
+		remotes[name] = url
+	}
+	return remotes, nil
+}
+
+// GitSetRemote Add a remote to a given git repository or update its URL. Returns whether the
+// remote was added or its URL changed
+func GitSetRemote(ctx context.Context, path string, name string, url string) (bool, error) {
+	current, err := RunGitCmdContext(ctx, path, "remote", nil, "get-url", name)
+	if err == nil && strings.TrimSpace(current) == url {
+		return false, nil
+	}
+	if err == nil {
+		_, err = RunGitCmdContext(ctx, path, "remote", nil, "set-url", name, url)
+	} else {
+		_, err = RunGitCmdContext(ctx, path, "remote", nil, "add", name, url)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to set remote %s of %s. Error: %w", name, path, err)
+	}
+	return true, nil
+}
+
+// GitFetchRemote Fetch the branches of a remote of a given git repository
+func GitFetchRemote(ctx context.Context, path string, name string, enablePrompt bool) error {
+	envConfig := []string{"GIT_TERMINAL_PROMPT=0"}
+	if enablePrompt {
+		envConfig = []string{"GIT_TERMINAL_PROMPT=1"}
+	}
+	if _, err := RunGitCmdContext(ctx, path, "fetch", envConfig, "-q", name); err != nil {
+		return fmt.Errorf("failed to fetch remote %s of %s. Error: %w", name, path, err)
+	}
+	return nil
+}
+
+// GitUpstream Get the remote branch tracked by the current branch of a given git repository.
+// Empty when the branch tracks none or HEAD is detached
+func GitUpstream(ctx context.Context, path string) (string, error) {
+	headRef, err := RunGitCmdContext(ctx, path, "symbolic-ref", nil, "-q", "HEAD")
+	if err != nil {
+		return "", nil
+	}
+	output, err := RunGitCmdContext(ctx, path, "for-each-ref", nil, "--format=%(upstream:short)", strings.TrimSpace(headRef))
+	if err != nil {
+		return "", fmt.Errorf("failed to get upstream of %s. Error: %w", path, err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// GitExactTag Get a tag pointing at HEAD of a given git repository. Empty when there is none
+func GitExactTag(ctx context.Context, path string) (string, error) {
+	output, err := RunGitCmdContext(ctx, path, "describe", nil, "--exact-match", "--tags", "HEAD")
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 128 {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to describe HEAD of %s. Error: %w", path, err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// GitUnpublished Get the reasons why a given version of a git repository cannot be reproduced from
+// origin: uncommitted changes, a version missing on origin, or commits of HEAD not pushed to origin.
+// Commits are compared with the references of origin known locally. When HEAD is not reachable
+// from them and origin has references not fetched yet, origin is fetched and HEAD checked again
+func GitUnpublished(ctx context.Context, path string, version string) ([]string, error) {
+	var reasons []string
+	status, err := RunGitCmdContext(ctx, path, "status", nil, "--porcelain")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check Git status of %s. Error: %w", path, err)
+	}
+	if strings.TrimSpace(status) != "" {
+		reasons = append(reasons, "uncommitted changes")
+	}
+
+	output, err := RunGitCmdContext(ctx, path, "ls-remote", []string{"GIT_TERMINAL_PROMPT=0"}, "origin")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the references of origin of %s. Error: %w", path, err)
+	}
+	remoteRefs := make(map[string]bool)
+	var remoteShas strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		sha, ref, found := strings.Cut(line, "\t")
+		if !found {
+			continue
+		}
+		remoteRefs[ref] = true
+		remoteShas.WriteString(sha + "\n")
+	}
+	if !IsValidSha(version) && !remoteRefs["refs/heads/"+version] && !remoteRefs["refs/tags/"+version] {
+		reasons = append(reasons, fmt.Sprintf("version '%s' not found on origin", version))
+	}
+
+	pushed, err := gitReachableFrom(ctx, path, remoteShas.String())
+	if err == nil && !pushed && remoteShas.Len() > 0 {
+		missing, missingErr := RunGitCmdInput(ctx, path, "cat-file", nil, remoteShas.String(), "--batch-check")
+		if missingErr != nil {
+			return nil, fmt.Errorf("failed to check the objects of %s. Error: %w", path, missingErr)
+		}
+		if strings.Contains(missing, " missing\n") {
+			if _, err := RunGitCmdContext(ctx, path, "fetch", []string{"GIT_TERMINAL_PROMPT=0"}, "--quiet", "--tags", "origin"); err != nil {
+				return nil, fmt.Errorf("failed to fetch origin of %s. Error: %w", path, err)
+			}
+			pushed, err = gitReachableFrom(ctx, path, remoteShas.String())
+		}
+	}
 	if err != nil {
-		PrintErrorMsg(fmt.Sprintf("Failed to get URL for the origin remote of %s. Error: %s", path, err))
+		return nil, fmt.Errorf("failed to find unpushed commits of %s. Error: %w", path, err)
+	}
+	if !pushed {
+		reasons = append(reasons, "commits not pushed to origin")
 	}
-	return strings.TrimSpace(output)
+	return reasons, nil
+}
+
+// gitReachableFrom Check if HEAD is reachable from any of the given newline separated commits.
+// Commits not available locally are ignored. They are read from stdin, as there may be thousands
+func gitReachableFrom(ctx context.Context, path string, shas string) (bool, error) {
+	var excluded strings.Builder
+	for _, sha := range strings.Fields(shas) {
+		excluded.WriteString("^" + sha + "\n")
+	}
+	output, err := RunGitCmdInput(ctx, path, "rev-list", nil, excluded.String(),
+		"--ignore-missing", "-n", "1", "--stdin", "HEAD")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(output) == "", nil
+}
+
+func GetGitRemoteURL(path string) string {
+	output, err := GitRemoteURL(context.Background(), path)
+	if err != nil {
+		PrintErrorMsg(err.Error())
+	}
+	return output
+}
+
+// GitPull Execute git pull in a given path with the given extra arguments
+func GitPull(ctx context.Context, path string, args ...string) (string, error) {
+	output, err := RunGitCmdContext(ctx, path, "pull", nil, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to pull Git repository %s. Error: %w", path, err)
+	}
+	return output, nil
 }
 
 // PullGitRepo Execute git pull in a given path
 func PullGitRepo(path string) string {
-	output, err := RunGitCmd(path, "pull", nil)
+	output, err := GitPull(context.Background(), path)
 	if err != nil {
-		PrintErrorMsg(fmt.Sprintf("Failed to pull Git repository %s. Error: %s", path, err))
+		PrintErrorMsg(err.Error())
 	}
 	return output
 }
 
+// GitStash Execute git stash in a given path
+func GitStash(ctx context.Context, path string, stashCmd string) (string, error) {
+	output, err := RunGitCmdContext(ctx, path, "stash", nil, []string{stashCmd}...)
+	if err != nil {
+		return "", fmt.Errorf("failed to run stash with %s Git repository %s. Error: %w", stashCmd, path, err)
+	}
+	return output, nil
+}
+
 // StashGitRepo Execute git stash in a given path
 func StashGitRepo(path string, stashCmd string) string {
-	output, err := RunGitCmd(path, "stash", nil, []string{stashCmd}...)
+	output, err := GitStash(context.Background(), path, stashCmd)
 	if err != nil {
-		PrintErrorMsg(fmt.Sprintf("Failed to run stash with %s Git repository %s. Error: %s", stashCmd, path, err))
+		PrintErrorMsg(err.Error())
 	}
 	return output
 }
 
+// GitSync Stash local changes, pull the latest remote with the given extra arguments and bring
+// back the stashed changes
+func GitSync(ctx context.Context, path string, pullArgs ...string) (string, error) {
+	output, err := GitStash(ctx, path, "push")
+	if err != nil {
+		return output, err
+	}
+	pullOutput, pullErr := GitPull(ctx, path, pullArgs...)
+	output += pullOutput
+
+	// Bring back the stashed changes even if the pull failed
+	stashList, err := GitStash(ctx, path, "list")
+	if err == nil && stashList != "" {
+		var popOutput string
+		popOutput, err = GitStash(ctx, path, "pop")
+		output += popOutput
+	}
+	return output, errors.Join(pullErr, err)
+}
+
+// SubmoduleState State of a submodule of a git repository
+type SubmoduleState struct {
+	// Path Path of the submodule relative to the repository
+	Path string
+	// Commit Commit checked out, or recorded by the repository when not initialized
+	Commit string
+	// State Either SubmoduleClean, SubmoduleNotInitialized, SubmoduleOutOfDate, SubmoduleConflict or SubmoduleModified
+	State string
+}
+
+// States of a submodule compared to the commit recorded by its repository
+const (
+	SubmoduleClean          = "clean"
+	SubmoduleNotInitialized = "not initialized"
+	SubmoduleOutOfDate      = "out of date"
+	SubmoduleConflict       = "merge conflicts"
+	SubmoduleModified       = "modified"
+)
+
+// GitSubmodules Get the state of the submodules of a given git repository, nested ones included
+func GitSubmodules(ctx context.Context, path string) ([]SubmoduleState, error) {
+	if _, err := os.Stat(filepath.Join(path, ".gitmodules")); err != nil {
+		return nil, nil
+	}
+	output, err := RunGitCmdContext(ctx, path, "submodule", nil, "status", "--recursive")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the submodules of %s. Error: %w", path, err)
+	}
+	var submodules []SubmoduleState
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		// Lines are "<state><commit> <path>", followed by " (<describe>)" for checked out submodules
+		if len(line) < 2 {
+			continue
+		}
+		commit, subPath, found := strings.Cut(line[1:], " ")
+		if !found {
+			continue
+		}
+		if i := strings.LastIndex(subPath, " ("); i >= 0 && strings.HasSuffix(subPath, ")") {
+			subPath = subPath[:i]
+		}
+		submodule := SubmoduleState{Path: subPath, Commit: commit, State: SubmoduleClean}
+		switch line[0] {
+		case '-':
+			submodule.State = SubmoduleNotInitialized
+		case '+':
+			submodule.State = SubmoduleOutOfDate
+		case 'U':
+			submodule.State = SubmoduleConflict
+		default:
+			status, err := RunGitCmdContext(ctx, filepath.Join(path, submodule.Path), "status", nil, "--porcelain")
+			if err != nil {
+				return nil, fmt.Errorf("failed to check Git status of submodule %s of %s. Error: %w", submodule.Path, path, err)
+			}
+			if strings.TrimSpace(status) != "" {
+				submodule.State = SubmoduleModified
+			}
+		}
+		submodules = append(submodules, submodule)
+	}
+	return submodules, nil
+}
+
+// GitSubmoduleUpdate Initialize the submodules of a given git repository and check out the commits it records
+func GitSubmoduleUpdate(ctx context.Context, path string, recursive bool) (string, error) {
+	args := []string{"update", "--init"}
+	if recursive {
+		args = append(args, "--recursive")
+	}
+	output, err := RunGitCmdContext(ctx, path, "submodule", []string{"GIT_TERMINAL_PROMPT=0"}, args...)
+	if err != nil {
+		return output, fmt.Errorf("failed to update the submodules of %s. Error: %w", path, err)
+	}
+	return output, nil
+}
+
+// IsGitSubmodule Check if a directory is the checkout of a submodule, whose .git file points
+// into the modules of its parent repository
+func IsGitSubmodule(dir string) bool {
+	content, err := os.ReadFile(filepath.Join(dir, ".git"))
+	if err != nil {
+		return false
+	}
+	gitDir := strings.TrimSpace(strings.TrimPrefix(string(content), "gitdir:"))
+	return strings.Contains(filepath.ToSlash(gitDir), "/modules/") || strings.HasPrefix(filepath.ToSlash(gitDir), "modules/")
+}
+
 // SyncGitRepo Handle syncronization of a git repo
 func SyncGitRepo(path string) string {
-	output := StashGitRepo(path, "push")
-	output += PullGitRepo(path)
-	if StashGitRepo(path, "list") != "" {
-		output += StashGitRepo(path, "pop")
+	output, err := GitSync(context.Background(), path)
+	if err != nil {
+		PrintErrorMsg(err.Error())
 	}
 	return output
 }
 
 // IsGitURLValid Check if a git URL is reachable
 func IsGitURLValid(url string, version string, enablePrompt bool) (bool, error) {
+	return IsGitURLValidContext(context.Background(), url, version, enablePrompt)
+}
+
+// IsGitURLValidContext Check if a git URL is reachable giving up once the context is done
+func IsGitURLValidContext(ctx context.Context, url string, version string, enablePrompt bool) (bool, error) {
 	var envConfig []string
 	if enablePrompt {
 		envConfig = []string{"GIT_TERMINAL_PROMPT=1"}
@@ -158,7 +573,7 @@ func IsGitURLValid(url string, version string, enablePrompt bool) (bool, error)
 		} else {
 			urlArgs = []string{url, version}
 		}
-		output, err = RunGitCmd(".", "ls-remote", envConfig, urlArgs...)
+		output, err = RunGitCmdContext(ctx, ".", "ls-remote", envConfig, urlArgs...)
 	}
 	if err != nil || len(output) == 0 {
 		return false, err
@@ -166,8 +581,119 @@ func IsGitURLValid(url string, version string, enablePrompt bool) (bool, error)
 	return true, nil
 }
 
-// GetGitLog Get logs for a given git repository
-func GetGitLog(path string, oneline bool, numCommits int) string {
+// ResolveGitVersion Get the commit SHA a branch, tag or empty version points to in a remote repository
+func ResolveGitVersion(ctx context.Context, url string, version string, enablePrompt bool) (string, error) {
+	if IsValidSha(version) {
+		return version, nil
+	}
+	var envConfig []string
+	if enablePrompt {
+		envConfig = []string{"GIT_TERMINAL_PROMPT=1"}
+	} else {
+		envConfig = []string{"GIT_TERMINAL_PROMPT=0"}
+	}
+
+	var candidates []string
+	if version == "" {
+		candidates = []string{"HEAD"}
+	} else {
+		// Prefer branches and for annotated tags the commit they point to
+		candidates = []string{"refs/heads/" + version, "refs/tags/" + version + "^{}", "refs/tags/" + version}
+	}
+	lsRemoteArgs := []string{url}
+	if version == "" {
+		lsRemoteArgs = append(lsRemoteArgs, "HEAD")
+	} else {
+		// The peeled pattern is needed to list the commit of annotated tags
+		lsRemoteArgs = append(lsRemoteArgs, version, version+"^{}")
+	}
+	output, err := RunGitCmdContext(ctx, ".", "ls-remote", envConfig, lsRemoteArgs...)
+	if err != nil {
+		return "", fmt.Errorf("failed to contact git repository '%s'. Error: %w", url, err)
+	}
+
+	refs := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			refs[fields[1]] = fields[0]
+		}
+	}
+	for _, candidate := range candidates {
+		if sha, ok := refs[candidate]; ok {
+			return sha, nil
+		}
+	}
+	return "", fmt.Errorf("version '%s' not found in git repository '%s'", version, url)
+}
+
+// FetchGitCommit Fetch a single commit of a remote repository into a new directory
+func FetchGitCommit(ctx context.Context, url string, sha string, path string) error {
+	envConfig := []string{"GIT_TERMINAL_PROMPT=0"}
+	if _, err := RunGitCmdContext(ctx, ".", "init", nil, "-q", path); err != nil {
+		return fmt.Errorf("failed to create repository %s. Error: %w", path, err)
+	}
+	if _, err := RunGitCmdContext(ctx, path, "fetch", envConfig, "-q", "--depth", "1", url, sha); err != nil {
+		return fmt.Errorf("failed to fetch %s from %s. Error: %w", sha, url, err)
+	}
+	if _, err := RunGitCmdContext(ctx, path, "checkout", nil, "-q", "--detach", "FETCH_HEAD"); err != nil {
+		return fmt.Errorf("failed to checkout %s in %s. Error: %w", sha, path, err)
+	}
+	return nil
+}
+
+// BundleRef Reference pointing to the bundled commit in the bundles created by CreateGitBundle
+const BundleRef = "refs/heads/rv-bundle"
+
+// CreateGitBundle Write a git bundle with the history of a commit of a remote repository
+func CreateGitBundle(ctx context.Context, url string, sha string, bundlePath string, enablePrompt bool) error {
+	var envConfig []string
+	if enablePrompt {
+		envConfig = []string{"GIT_TERMINAL_PROMPT=1"}
+	} else {
+		envConfig = []string{"GIT_TERMINAL_PROMPT=0"}
+	}
+	repoPath := bundlePath + ".git"
+	defer os.RemoveAll(repoPath)
+
+	if _, err := RunGitCmdContext(ctx, ".", "init", nil, "-q", "--bare", repoPath); err != nil {
+		return fmt.Errorf("failed to create repository %s. Error: %w", repoPath, err)
+	}
+	if _, err := RunGitCmdContext(ctx, repoPath, "fetch", envConfig, "-q", url, sha+":"+BundleRef); err != nil {
+		return fmt.Errorf("failed to fetch %s from %s. Error: %w", sha, url, err)
+	}
+	if _, err := RunGitCmdContext(ctx, repoPath, "bundle", nil, "create", "-q", bundlePath, BundleRef); err != nil {
+		return fmt.Errorf("failed to create bundle %s. Error: %w", bundlePath, err)
+	}
+	return nil
+}
+
+// CloneGitBundle Check out the commit of a bundle created by CreateGitBundle using url as origin
+// An existing repository is switched to the commit instead
+func CloneGitBundle(ctx context.Context, bundlePath string, url string, sha string, path string) error {
+	existing := IsGitRepository(path)
+	if !existing {
+		if _, err := RunGitCmdContext(ctx, ".", "init", nil, "-q", path); err != nil {
+			return fmt.Errorf("failed to create repository %s. Error: %w", path, err)
+		}
+	}
+	if _, err := RunGitCmdContext(ctx, path, "fetch", nil, "-q", bundlePath, BundleRef); err != nil {
+		return fmt.Errorf("failed to fetch %s from bundle %s. Error: %w", sha, bundlePath, err)
+	}
+	if _, err := RunGitCmdContext(ctx, path, "checkout", nil, "-q", "--detach", sha); err != nil {
+		return fmt.Errorf("failed to checkout %s in %s. Error: %w", sha, path, err)
+	}
+	if existing {
+		return nil
+	}
+	if _, err := RunGitCmdContext(ctx, path, "remote", nil, "add", "origin", url); err != nil {
+		return fmt.Errorf("failed to set origin of %s. Error: %w", path, err)
+	}
+	return nil
+}
+
+// GitLog Get logs for a given git repository
+func GitLog(ctx context.Context, path string, oneline bool, numCommits int) (string, error) {
 	var cmdArgs []string
 
 	if oneline {
@@ -176,15 +702,29 @@ func GetGitLog(path string, oneline bool, numCommits int) string {
 		cmdArgs = []string{"-n", strconv.Itoa(numCommits)}
 	}
 
-	output, err := RunGitCmd(path, "log", nil, cmdArgs...)
+	output, err := RunGitCmdContext(ctx, path, "log", nil, cmdArgs...)
 	if err != nil {
-		PrintErrorMsg(fmt.Sprintf("Failed to check Git log of %s. Error: %s", path, err))
+		return "", fmt.Errorf("failed to check Git log of %s. Error: %w", path, err)
+	}
+	return output, nil
+}
+
+// GetGitLog Get logs for a given git repository
+func GetGitLog(path string, oneline bool, numCommits int) string {
+	output, err := GitLog(context.Background(), path, oneline, numCommits)
+	if err != nil {
+		PrintErrorMsg(err.Error())
 	}
 	return output
 }
 
 // GitSwitch Switch version for a given git repository
 func GitSwitch(path string, branch string, createBranch bool, detachHead bool) (string, error) {
+	return GitSwitchContext(context.Background(), path, branch, createBranch, detachHead)
+}
+
+// GitSwitchContext Switch version for a given git repository giving up once the context is done
+func GitSwitchContext(ctx context.Context, path string, branch string, createBranch bool, detachHead bool) (string, error) {
 
 	cmdArgs := []string{}
 
@@ -195,9 +735,9 @@ func GitSwitch(path string, branch string, createBranch bool, detachHead bool) (
 	}
 	cmdArgs = append(cmdArgs, branch)
 
-	output, err := RunGitCmd(path, "switch", nil, cmdArgs...)
+	output, err := RunGitCmdContext(ctx, path, "switch", nil, cmdArgs...)
 	if err != nil {
-		switchError := fmt.Errorf("failed to switch branch of repository %s to %s. Error: %s", path, branch, err)
+		switchError := fmt.Errorf("failed to switch branch of repository %s to %s. Error: %w", path, branch, err)
 		return "", switchError
 	}
 	return output, nil
@@ -210,25 +750,83 @@ func IsValidSha(sha string) bool {
 
 }
 
+// fetchMissingCommit Fetch a commit from origin if it is not available in the repository
+func fetchMissingCommit(ctx context.Context, path string, sha string, shallow bool, envConfig []string) error {
+	if _, err := RunGitCmdContext(ctx, path, "cat-file", nil, "-e", sha+"^{commit}"); err == nil {
+		return nil
+	}
+	fetchArgs := []string{"origin", sha}
+	if shallow {
+		fetchArgs = append(fetchArgs, "--depth", "1")
+	}
+	if _, err := RunGitCmdContext(ctx, path, "fetch", envConfig, fetchArgs...); err != nil {
+		return fmt.Errorf("failed to fetch commit %s in repository %s. Error: %w", sha, path, err)
+	}
+	return nil
+}
+
+// CloneOptions Settings used to clone a repository
+type CloneOptions struct {
+	URL               string
+	Version           string
+	Path              string
+	OverwriteExisting bool
+	Shallow           bool
+	EnablePrompt      bool
+	RecurseSubmodules bool
+	// CacheDir Directory with the mirrors used as reference when cloning. Empty disables the cache
+	CacheDir string
+	// SHA256 Expected checksum of the archive of tar and zip repositories
+	SHA256 string
+	// StripComponents Number of leading path components removed when extracting tar and zip repositories
+	StripComponents int
+}
+
 // GitClone Clone a given repository URL
 func GitClone(url string, version string, clonePath string, overwriteExisting bool, shallowClone bool, enablePrompt bool, recurseSubmodules bool) int {
+	statusClone, _ := CloneGitRepo(context.Background(), CloneOptions{
+		URL:               url,
+		Version:           version,
+		Path:              clonePath,
+		OverwriteExisting: overwriteExisting,
+		Shallow:           shallowClone,
+		EnablePrompt:      enablePrompt,
+		RecurseSubmodules: recurseSubmodules,
+	})
+	return statusClone
+}
+
+// CloneGitRepo Clone a repository reporting the reason of a failure.
+// A new clone that fails or gets cancelled is removed to avoid leaving partial clones behind
+func CloneGitRepo(ctx context.Context, opts CloneOptions) (int, error) {
+	statusClone, newClone, err := cloneGitRepo(ctx, opts)
+	if statusClone == FailedClone && newClone {
+		os.RemoveAll(opts.Path)
+	}
+	return statusClone, err
+}
+
+// cloneGitRepo Clone a repository reporting whether a new clone was created
+func cloneGitRepo(ctx context.Context, opts CloneOptions) (int, bool, error) {
+	url := opts.URL
+	version := opts.Version
+	clonePath := opts.Path
 
 	// Check if clonePath exists
 	var skip_clone bool = false
 	if _, err := os.Stat(clonePath); err == nil {
-		if !overwriteExisting {
+		if !opts.OverwriteExisting {
 			skip_clone = true
 		} else {
 			// Remove existing clonePath
 			if err := os.RemoveAll(clonePath); err != nil {
-				PrintErrorMsg(fmt.Sprintf("Failed to remove existing cloning path %s. Error: %s\n", clonePath, err))
-				panic(err)
+				return FailedClone, false, fmt.Errorf("failed to remove existing cloning path %s. Error: %w", clonePath, err)
 			}
 		}
 	}
 
 	var envConfig []string
-	if enablePrompt {
+	if opts.EnablePrompt {
 		envConfig = []string{"GIT_TERMINAL_PROMPT=1"}
 	} else {
 		envConfig = []string{"GIT_TERMINAL_PROMPT=0"}
@@ -243,125 +841,59 @@ func GitClone(url string, version string, clonePath string, overwriteExisting bo
 		cmdArgs = []string{url, "--branch", version, clonePath}
 	}
 
-	if shallowClone {
+	if opts.Shallow {
 		cmdArgs = append(cmdArgs, "--depth", "1")
 	}
-	if recurseSubmodules {
+	if opts.RecurseSubmodules {
 		cmdArgs = append(cmdArgs, "--recurse-submodules")
-		if shallowClone {
+		if opts.Shallow {
 			cmdArgs = append(cmdArgs, "--shallow-submodules")
 		}
 	}
+	if !skip_clone && opts.CacheDir != "" {
+		// A stale mirror is still a valid reference, only objects missing from it are fetched
+		mirrorPath, err := UpdateCacheMirror(ctx, opts.CacheDir, url, version, opts.EnablePrompt)
+		if mirrorPath != "" && (err == nil || isBareRepository(mirrorPath)) {
+			// The mirror must not be updated or removed while the clone reads its objects
+			unlock, err := LockCacheMirror(ctx, mirrorPath, false)
+			if err != nil {
+				return FailedClone, false, err
+			}
+			defer unlock()
+			cmdArgs = append(cmdArgs, "--reference", mirrorPath, "--dissociate")
+		}
+	}
 	if !skip_clone {
-		if _, err := RunGitCmd(".", "clone", envConfig, cmdArgs...); err != nil {
-			return FailedClone
+		if _, err := RunGitCmdContext(ctx, ".", "clone", envConfig, cmdArgs...); err != nil {
+			return FailedClone, true, fmt.Errorf("failed to clone %s. Error: %w", url, err)
 		}
 	}
 
-	if skip_clone && (GetGitCommitSha(clonePath) == version || GetGitBranch(clonePath) == version) {
-		return SkippedClone
+	if skip_clone {
+		currentSha, _ := GitCommitSha(ctx, clonePath)
+		currentBranch, _ := GitBranch(ctx, clonePath)
+		if currentSha == version || currentBranch == version {
+			return SkippedClone, false, nil
+		}
 	}
 
 	if versionIsSha {
-		if _, err := GitSwitch(clonePath, version, false, true); err != nil {
-			return FailedClone
+		// The commit might not be part of the cloned history, e.g. for shallow clones
+		if err := fetchMissingCommit(ctx, clonePath, version, opts.Shallow, envConfig); err != nil {
+			return FailedClone, !skip_clone, err
+		}
+		if _, err := GitSwitchContext(ctx, clonePath, version, false, true); err != nil {
+			return FailedClone, !skip_clone, err
 		}
 		if skip_clone {
-			return SwitchedBranch
+			return SwitchedBranch, false, nil
 		}
 	} else if skip_clone {
-		if _, err := GitSwitch(clonePath, version, false, false); err != nil {
-			return FailedClone
-		}
-		return SwitchedBranch
-	}
-
-	return SuccessfullClone
-}
-
-// PrintGitLog Pretty print logs for a given git repository
-func PrintGitLog(path string, oneline bool, numCommits int) {
-	repoLogs := GetGitLog(path, oneline, numCommits)
-	PrintRepoEntry(path, string(repoLogs))
-}
-
-// PrintGitStatus Pretty print status for a given git repository
-func PrintGitStatus(path string, skipEmpty bool, plainStatus bool) {
-	repoStatus := GetGitStatus(path, plainStatus)
-
-	if plainStatus {
-		if skipEmpty && strings.Count(repoStatus, "\n") <= 1 {
-			return
-		}
-	} else {
-		if skipEmpty && strings.Contains(repoStatus, "working tree clean") {
-			return
+		if _, err := GitSwitchContext(ctx, clonePath, version, false, false); err != nil {
+			return FailedClone, false, err
 		}
+		return SwitchedBranch, false, nil
 	}
 
-	PrintRepoEntry(path, string(repoStatus))
-}
-
-// PrintGitPull Pretty print git pull output for a given git repository
-func PrintGitPull(path string) {
-	pullMsg := PullGitRepo(path)
-
-	PrintRepoEntry(path, string(pullMsg))
-}
-
-// PrintGitSync Pretty print git sync output for a given git repository
-func PrintGitSync(path string) {
-	syncMsg := SyncGitRepo(path)
-
-	PrintRepoEntry(path, string(syncMsg))
-}
-
-// PrintCheckGit Pretty print git url validation
-func PrintCheckGit(path string, url string, version string, enablePrompt bool) bool {
-	var checkMsg string
-	var isURLValid bool
-	if isURLValid, err := IsGitURLValid(url, version, enablePrompt); !isURLValid {
-		checkMsg = fmt.Sprintf("%sFailed to contact git repository '%s' with version '%s'. Error: %v%s\n", RedColor, url, version, err, ResetColor)
-	} else {
-		checkMsg = fmt.Sprintf("Successfully contact git repository '%s' with version '%s'\n", url, version)
-	}
-	PrintRepoEntry(path, checkMsg)
-	return isURLValid
-}
-
-// PrintGitClone Pretty print git clone
-func PrintGitClone(url string, version string, path string, overwriteExisting bool, shallowClone bool, enablePrompt bool, recurseSubmodules bool) bool {
-	var cloneMsg string
-	var cloneSuccessful bool
-	statusClone := GitClone(url, version, path, overwriteExisting, shallowClone, enablePrompt, recurseSubmodules)
-	switch statusClone {
-	case SuccessfullClone:
-		cloneMsg = fmt.Sprintf("Successfully cloned git repository '%s' with version '%s'\n", url, version)
-		cloneSuccessful = true
-	case SkippedClone:
-		cloneMsg = fmt.Sprintf("%sSkipped cloning existing git repository '%s'%s\n", OrangeColor, url, ResetColor)
-		cloneSuccessful = true
-	case FailedClone:
-		cloneMsg = fmt.Sprintf("%sFailed to clone git repository '%s' with version '%s'%s\n", RedColor, url, version, ResetColor)
-		cloneSuccessful = false
-	case SwitchedBranch:
-		cloneMsg = fmt.Sprintf("Successfully switched to version '%s' in existing git repository '%s'\n", version, url)
-		cloneSuccessful = true
-	default:
-		panic("Unexpected behavior!")
-	}
-	PrintRepoEntry(path, cloneMsg)
-	return cloneSuccessful
-}
-
-// PrintGitSwitch Pretty print git switch
-func PrintGitSwitch(path string, branch string, createBranch bool, detachHead bool) bool {
-	switchMsg, err := GitSwitch(path, branch, createBranch, detachHead)
-	if err == nil {
-		PrintRepoEntry(path, string(switchMsg))
-		return true
-	}
-	errorMsg := fmt.Sprintf("%sError: '%s'%s\n", RedColor, err, ResetColor)
-	PrintRepoEntry(path, string(errorMsg))
-	return false
+	return SuccessfullClone, true, nil
 }
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IsHgRepository checks if a directory is a Mercurial repository
+func IsHgRepository(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".hg"))
+	return err == nil
+}
+
+// RunHgCmdContext Helper method to execute a hg command that is killed once the context is done
+func RunHgCmdContext(ctx context.Context, path string, hgCmd string, enablePrompt bool, args ...string) (string, error) {
+	cmdArgs := []string{"--color", gitColorMode()}
+	if !enablePrompt {
+		cmdArgs = append(cmdArgs, "--noninteractive")
+	}
+	cmdArgs = append(cmdArgs, hgCmd)
+	cmdArgs = append(cmdArgs, args...)
+	cmd := exec.CommandContext(ctx, "hg", cmdArgs...)
+	// Keep the output stable regardless of the user configuration
+	cmd.Env = append(os.Environ(), "HGPLAIN=1")
+	cmd.Dir = path
+	cmd.WaitDelay = gitWaitDelay
+
+	output, err := cmd.CombinedOutput()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return "", ctxErr
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return string(output), nil
+}
+
+var hgNodeRegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// ResolveHgVersion Get the node id of the changeset a Mercurial version currently points to.
+// The empty version resolves to the head of the default branch
+func ResolveHgVersion(ctx context.Context, url string, version string, enablePrompt bool) (string, error) {
+	if version == "" {
+		version = "default"
+	}
+	// The debug flag prints the full node id instead of its short form, after any debug message
+	output, err := RunHgCmdContext(ctx, ".", "identify", enablePrompt, "--debug", "--id", "--rev", version, url)
+	if err != nil {
+		return "", fmt.Errorf("failed to contact Mercurial repository '%s' with version '%s'. Error: %w", url, version, err)
+	}
+	fields := strings.Fields(output)
+	if len(fields) == 0 || !hgNodeRegex.MatchString(fields[len(fields)-1]) {
+		return "", fmt.Errorf("unexpected node id of Mercurial repository '%s' with version '%s': %s", url, version, strings.TrimSpace(output))
+	}
+	return fields[len(fields)-1], nil
+}
+
+// hgVCS Mercurial repositories
+type hgVCS struct{}
+
+func (hgVCS) Type() string { return "hg" }
+
+func (hgVCS) IsRepository(dir string) bool { return IsHgRepository(dir) }
+
+func (hgVCS) Status(ctx context.Context, path string, plain bool) (string, error) {
+	statusCmd := "summary"
+	if plain {
+		statusCmd = "status"
+	}
+	output, err := RunHgCmdContext(ctx, path, statusCmd, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to check Mercurial status of %s. Error: %w", path, err)
+	}
+	return output, nil
+}
+
+func (hgVCS) IsCleanStatus(output string, plain bool) bool {
+	if plain {
+		return strings.TrimSpace(output) == ""
+	}
+	return strings.Contains(output, "commit: (clean)")
+}
+
+func (hgVCS) Log(ctx context.Context, path string, oneline bool, numCommits int) (string, error) {
+	logArgs := []string{"--limit", fmt.Sprint(numCommits)}
+	if oneline {
+		logArgs = append(logArgs, "--template", "{node|short} {desc|firstline}\n")
+	}
+	output, err := RunHgCmdContext(ctx, path, "log", false, logArgs...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get Mercurial log of %s. Error: %w", path, err)
+	}
+	return output, nil
+}
+
+func (hgVCS) Pull(ctx context.Context, path string) (string, error) {
+	output, err := RunHgCmdContext(ctx, path, "pull", false, "--update")
+	if err != nil {
+		return "", fmt.Errorf("failed to pull Mercurial repository %s. Error: %w", path, err)
+	}
+	return output, nil
+}
+
+func (hgVCS) Info(ctx context.Context, path string, useCommit bool) (Repository, error) {
+	var repository Repository
+	url, err := RunHgCmdContext(ctx, path, "paths", false, "default")
+	if err != nil {
+		return repository, fmt.Errorf("failed to get default path of %s. Error: %w", path, err)
+	}
+	var version string
+	if useCommit {
+		version, err = RunHgCmdContext(ctx, path, "log", false, "--rev", ".", "--template", "{node}")
+	} else {
+		version, err = RunHgCmdContext(ctx, path, "branch", false)
+	}
+	if err != nil {
+		return repository, fmt.Errorf("failed to get version of %s. Error: %w", path, err)
+	}
+	repository.Type = "hg"
+	repository.URL = strings.TrimSpace(url)
+	repository.Version = strings.TrimSpace(version)
+	return repository, nil
+}
+
+func (hgVCS) Validate(ctx context.Context, repo Repository, enablePrompt bool) error {
+	identifyArgs := []string{repo.URL}
+	if repo.Version != "" {
+		identifyArgs = append(identifyArgs, "--rev", repo.Version)
+	}
+	if _, err := RunHgCmdContext(ctx, ".", "identify", enablePrompt, identifyArgs...); err != nil {
+		return fmt.Errorf("failed to contact Mercurial repository '%s' with version '%s'. Error: %w", repo.URL, repo.Version, err)
+	}
+	return nil
+}
+
+func (vcs hgVCS) Clone(ctx context.Context, opts CloneOptions) (int, error) {
+	if _, err := os.Stat(opts.Path); err == nil {
+		if !opts.OverwriteExisting {
+			return vcs.updateExisting(ctx, opts)
+		}
+		if err := os.RemoveAll(opts.Path); err != nil {
+			return FailedClone, fmt.Errorf("failed to remove existing cloning path %s. Error: %w", opts.Path, err)
+		}
+	}
+
+	cloneArgs := []string{opts.URL, opts.Path}
+	if opts.Version != "" {
+		cloneArgs = append(cloneArgs, "--updaterev", opts.Version)
+	}
+	if _, err := RunHgCmdContext(ctx, ".", "clone", opts.EnablePrompt, cloneArgs...); err != nil {
+		os.RemoveAll(opts.Path)
+		return FailedClone, fmt.Errorf("failed to clone %s. Error: %w", opts.URL, err)
+	}
+	return SuccessfullClone, nil
+}
+
+// updateExisting Update an existing working copy to the requested version
+func (vcs hgVCS) updateExisting(ctx context.Context, opts CloneOptions) (int, error) {
+	if opts.Version == "" {
+		return SkippedClone, nil
+	}
+	if current, err := vcs.Info(ctx, opts.Path, false); err == nil && current.Version == opts.Version {
+		return SkippedClone, nil
+	}
+	if current, err := vcs.Info(ctx, opts.Path, true); err == nil && strings.HasPrefix(current.Version, opts.Version) {
+		return SkippedClone, nil
+	}
+	if _, err := RunHgCmdContext(ctx, opts.Path, "pull", opts.EnablePrompt); err != nil {
+		return FailedClone, fmt.Errorf("failed to pull Mercurial repository %s. Error: %w", opts.Path, err)
+	}
+	if _, err := RunHgCmdContext(ctx, opts.Path, "update", false, "--rev", opts.Version); err != nil {
+		return FailedClone, fmt.Errorf("failed to update %s to %s. Error: %w", opts.Path, opts.Version, err)
+	}
+	return SwitchedBranch, nil
+}
@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFileName Name of the files listing, in gitignore syntax, the paths that are not searched
+// for repositories and .repos files
+const IgnoreFileName = ".rvignore"
+
+// ignoreRule Single pattern of an ignore file
+type ignoreRule struct {
+	expression *regexp.Regexp
+	negate     bool
+	dirOnly    bool
+}
+
+// IgnoreMatcher Patterns in gitignore syntax matched against paths relative to a base directory
+type IgnoreMatcher struct {
+	base  string
+	rules []ignoreRule
+}
+
+// NewIgnoreMatcher Create a matcher for patterns in gitignore syntax relative to the given directory
+func NewIgnoreMatcher(base string, patterns []string) (*IgnoreMatcher, error) {
+	matcher := &IgnoreMatcher{base: base}
+	for _, pattern := range patterns {
+		rule, ok, err := parseIgnorePattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern '%s'. Error: %w", pattern, err)
+		}
+		if ok {
+			matcher.rules = append(matcher.rules, rule)
+		}
+	}
+	return matcher, nil
+}
+
+// ReadIgnoreFile Load the .rvignore file of a directory. Nil when the directory has none
+func ReadIgnoreFile(dir string) (*IgnoreMatcher, error) {
+	file, err := os.Open(filepath.Join(dir, IgnoreFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		patterns = append(patterns, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s. Error: %w", file.Name(), err)
+	}
+	matcher, err := NewIgnoreMatcher(dir, patterns)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file.Name(), err)
+	}
+	return matcher, nil
+}
+
+// match Check if a path matches any rule. The last matching rule decides whether it is ignored
+func (m *IgnoreMatcher) match(path string, isDir bool) (matched bool, ignored bool) {
+	relPath, err := filepath.Rel(m.base, path)
+	if err != nil || relPath == "." || !filepath.IsLocal(relPath) {
+		return false, false
+	}
+	relPath = filepath.ToSlash(relPath)
+	for i := len(m.rules) - 1; i >= 0; i-- {
+		rule := m.rules[i]
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.expression.MatchString(relPath) {
+			return true, !rule.negate
+		}
+	}
+	return false, false
+}
+
+// IgnoreStack Matchers of the ignore files found from a root down to a directory
+type IgnoreStack []*IgnoreMatcher
+
+// Push Get a stack with the given matcher on top. The stack itself is left unchanged
+func (s IgnoreStack) Push(matcher *IgnoreMatcher) IgnoreStack {
+	if matcher == nil {
+		return s
+	}
+	return append(s[:len(s):len(s)], matcher)
+}
+
+// IsIgnored Check if a path is ignored. Matchers of deeper directories take precedence
+func (s IgnoreStack) IsIgnored(path string, isDir bool) bool {
+	for i := len(s) - 1; i >= 0; i-- {
+		if matched, ignored := s[i].match(path, isDir); matched {
+			return ignored
+		}
+	}
+	return false
+}
+
+// parseIgnorePattern Convert a line of an ignore file into a rule. Blank lines and comments
+// are not rules
+func parseIgnorePattern(pattern string) (ignoreRule, bool, error) {
+	var rule ignoreRule
+	if !strings.HasSuffix(pattern, "\\ ") {
+		pattern = strings.TrimRight(pattern, " \t")
+	}
+	if pattern == "" || strings.HasPrefix(pattern, "#") {
+		return rule, false, nil
+	}
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	} else if strings.HasPrefix(pattern, "\\!") || strings.HasPrefix(pattern, "\\#") {
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	// Patterns with a slash other than a trailing one are relative to the ignore file
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+	if pattern == "" {
+		return rule, false, nil
+	}
+
+	var expression strings.Builder
+	expression.WriteString("^")
+	if !anchored {
+		expression.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**") && (i == 0 || pattern[i-1] == '/') {
+				switch {
+				case i+2 == len(pattern):
+					expression.WriteString(".*")
+					i++
+					continue
+				case pattern[i+2] == '/':
+					expression.WriteString("(?:.*/)?")
+					i += 2
+					continue
+				}
+			}
+			expression.WriteString("[^/]*")
+		case '?':
+			expression.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				expression.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expression.WriteString("[" + strings.ReplaceAll(class, "\\", "\\\\") + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				expression.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			}
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	// A matching directory ignores everything inside it
+	expression.WriteString("(?:/.*)?$")
+	compiled, err := regexp.Compile(expression.String())
+	if err != nil {
+		return rule, false, err
+	}
+	rule.expression = compiled
+	return rule, true, nil
+}
@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Supported output formats
+const (
+	TextOutput   = "text"
+	JSONOutput   = "json"
+	NDJSONOutput = "ndjson"
+)
+
+// RepoResult Outcome of running an operation on a single repository
+type RepoResult struct {
+	Path       string
+	Operation  string
+	Success    bool
+	Skipped    bool
+	Output     string
+	Err        error
+	Repository *Repository
+	// Stderr Error output of commands run by exec, kept apart from Output
+	Stderr string
+	// ExitCode Exit code of commands run by exec
+	ExitCode *int
+}
+
+type repoResultJSON struct {
+	Path       string      `json:"path"`
+	Operation  string      `json:"operation"`
+	Success    bool        `json:"success"`
+	Skipped    bool        `json:"skipped,omitempty"`
+	Output     string      `json:"output"`
+	Error      string      `json:"error,omitempty"`
+	Repository *Repository `json:"repository,omitempty"`
+	Stderr     string      `json:"stderr,omitempty"`
+	ExitCode   *int        `json:"exit_code,omitempty"`
+}
+
+// MarshalJSON Encode the result using the error message instead of the error value
+func (r RepoResult) MarshalJSON() ([]byte, error) {
+	encoded := repoResultJSON{
+		Path:       r.Path,
+		Operation:  r.Operation,
+		Success:    r.Success,
+		Skipped:    r.Skipped,
+		Output:     r.Output,
+		Repository: r.Repository,
+		Stderr:     r.Stderr,
+		ExitCode:   r.ExitCode,
+	}
+	if r.Err != nil {
+		encoded.Error = r.Err.Error()
+	}
+	return json.Marshal(encoded)
+}
+
+var (
+	outputFormat    = TextOutput
+	outputMutex     sync.Mutex
+	bufferedResults = []RepoResult{}
+)
+
+// SetOutputFormat Select how repository results are printed
+func SetOutputFormat(format string) error {
+	switch format {
+	case TextOutput, JSONOutput, NDJSONOutput:
+		outputFormat = format
+		return nil
+	}
+	return fmt.Errorf("invalid output format '%s'. Expected one of: %s, %s, %s", format, TextOutput, JSONOutput, NDJSONOutput)
+}
+
+// GetOutputFormat Get the currently selected output format
+func GetOutputFormat() string {
+	return outputFormat
+}
+
+// IsStructuredOutput Check if results are printed in a machine-readable format
+func IsStructuredOutput() bool {
+	return outputFormat != TextOutput
+}
+
+// PrintRepoResult Print a repository result using the selected output format
+func PrintRepoResult(result RepoResult) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+
+	switch outputFormat {
+	case JSONOutput:
+		bufferedResults = append(bufferedResults, result)
+	case NDJSONOutput:
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			PrintErrorMsg(fmt.Sprintf("Failed to encode result of %s. Error: %s", result.Path, err))
+			return
+		}
+		fmt.Println(string(encoded))
+	default:
+		msg := result.Output
+		if msg != "" && !result.Success {
+			msg = RedColor + strings.TrimSuffix(msg, "\n") + ResetColor + "\n"
+		} else if msg != "" && result.Skipped {
+			msg = OrangeColor + strings.TrimSuffix(msg, "\n") + ResetColor + "\n"
+		}
+		if result.Stderr != "" {
+			// Keep the error output of commands on its own lines
+			if msg != "" && !strings.HasSuffix(msg, "\n") {
+				msg += "\n"
+			}
+			msg += OrangeColor + strings.TrimSuffix(result.Stderr, "\n") + ResetColor + "\n"
+		}
+		PrintRepoEntry(result.Path, msg)
+		if result.Err != nil {
+			PrintErrorMsg(fmt.Sprintf("Error: %s", result.Err))
+		}
+	}
+}
+
+// FlushRepoResults Print any buffered results. Only relevant for the json output format
+func FlushRepoResults() {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+
+	if outputFormat != JSONOutput {
+		return
+	}
+	encoded, err := json.MarshalIndent(bufferedResults, "", "  ")
+	if err != nil {
+		PrintErrorMsg(fmt.Sprintf("Failed to encode results. Error: %s", err))
+		return
+	}
+	fmt.Println(string(encoded))
+	bufferedResults = []RepoResult{}
+}
+
+// messageWriter Get where informative messages should be written to.
+// Structured output keeps stdout reserved for the results.
+func messageWriter() *os.File {
+	if IsStructuredOutput() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// gitColorMode Get the value for git's color.ui setting
+func gitColorMode() string {
+	if IsStructuredOutput() {
+		return "never"
+	}
+	return "always"
+}
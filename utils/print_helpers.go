@@ -16,20 +16,20 @@ func PrintRepoEntry(path string, msg string) {
 }
 
 func PrintSection(msg string) {
-	fmt.Printf("%s%s%s\n", GreenColor, msg, ResetColor)
+	fmt.Fprintf(messageWriter(), "%s%s%s\n", GreenColor, msg, ResetColor)
 }
 func PrintSeparator() {
-	fmt.Printf("%s--------------------%s\n", PurpleColor, ResetColor)
+	fmt.Fprintf(messageWriter(), "%s--------------------%s\n", PurpleColor, ResetColor)
 }
 
 func PrintInfoMsg(msg string) {
-	fmt.Printf("%s%s%s", BlueColor, msg, ResetColor)
+	fmt.Fprintf(messageWriter(), "%s%s%s", BlueColor, msg, ResetColor)
 }
 
 func PrintWarnMsg(msg string) {
-	fmt.Printf("%s%s%s", OrangeColor, msg, ResetColor)
+	fmt.Fprintf(messageWriter(), "%s%s%s", OrangeColor, msg, ResetColor)
 }
 
 func PrintErrorMsg(msg string) {
-	fmt.Printf("%s%s%s\n", RedColor, msg, ResetColor)
+	fmt.Fprintf(messageWriter(), "%s%s%s\n", RedColor, msg, ResetColor)
 }
@@ -0,0 +1,275 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GitProgress Progress reported by git while transferring objects
+type GitProgress struct {
+	Phase   string
+	Percent int
+}
+
+type gitProgressKey struct{}
+
+// WithGitProgress Get a context that reports the progress of clone, fetch and pull git commands
+func WithGitProgress(ctx context.Context, report func(progress GitProgress)) context.Context {
+	return context.WithValue(ctx, gitProgressKey{}, report)
+}
+
+// gitProgressReporter Get the progress reporter of the context if the git command supports it
+func gitProgressReporter(ctx context.Context, gitCmd string) func(GitProgress) {
+	switch gitCmd {
+	case "clone", "fetch", "pull":
+	default:
+		return nil
+	}
+	report, _ := ctx.Value(gitProgressKey{}).(func(GitProgress))
+	return report
+}
+
+// Matches lines such as "Receiving objects:  45% (450/1000), 1.20 MiB | 2.00 MiB/s"
+var gitProgressRegex = regexp.MustCompile(`^(?:remote: )?([A-Za-z][A-Za-z ]*):\s+(\d+)%`)
+
+// ParseGitProgress Parse a progress line printed by git
+func ParseGitProgress(line string) (GitProgress, bool) {
+	matches := gitProgressRegex.FindStringSubmatch(strings.TrimSpace(line))
+	if matches == nil {
+		return GitProgress{}, false
+	}
+	percent, _ := strconv.Atoi(matches[2])
+	return GitProgress{Phase: matches[1], Percent: percent}, true
+}
+
+// progressWriter Split git output reporting progress lines and keeping everything else
+type progressWriter struct {
+	mutex  sync.Mutex
+	output io.Writer
+	report func(GitProgress)
+	line   []byte
+}
+
+func (w *progressWriter) Write(data []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for _, char := range data {
+		switch char {
+		case '\r', '\n':
+			w.handleLine(char)
+		default:
+			w.line = append(w.line, char)
+		}
+	}
+	return len(data), nil
+}
+
+// Flush Handle any pending incomplete line
+func (w *progressWriter) Flush() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if len(w.line) > 0 {
+		w.handleLine('\n')
+	}
+}
+
+func (w *progressWriter) handleLine(terminator byte) {
+	line := string(w.line)
+	w.line = w.line[:0]
+	if progress, ok := ParseGitProgress(line); ok {
+		w.report(progress)
+		return
+	}
+	if terminator == '\r' && line == "" {
+		return
+	}
+	w.output.Write([]byte(line + "\n"))
+}
+
+// IsTerminal Check if the given file is an interactive terminal
+func IsTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalWidth Get the width of the terminal from the environment
+func terminalWidth() int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return 100
+}
+
+type repoProgress struct {
+	phase   string
+	percent int
+	started time.Time
+}
+
+// ProgressRenderer Live view of the repositories being processed, one line per active repository
+type ProgressRenderer struct {
+	mutex     sync.Mutex
+	out       io.Writer
+	active    map[string]*repoProgress
+	total     int
+	finished  int
+	started   time.Time
+	drawn     int
+	stop      chan struct{}
+	stopped   chan struct{}
+	operation string
+}
+
+// NewProgressRenderer Create a renderer writing to stdout
+func NewProgressRenderer(operation string) *ProgressRenderer {
+	return &ProgressRenderer{
+		out:       os.Stdout,
+		active:    make(map[string]*repoProgress),
+		operation: operation,
+	}
+}
+
+// Start Periodically redraw the progress view until Stop is called
+func (p *ProgressRenderer) Start() {
+	p.started = time.Now()
+	p.stop = make(chan struct{})
+	p.stopped = make(chan struct{})
+	go func() {
+		defer close(p.stopped)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.mutex.Lock()
+				p.redraw()
+				p.mutex.Unlock()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop Stop redrawing and remove the progress view
+func (p *ProgressRenderer) Stop() {
+	close(p.stop)
+	<-p.stopped
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.clear()
+}
+
+// AddTotal Increase the number of repositories to process
+func (p *ProgressRenderer) AddTotal(count int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.total += count
+}
+
+// RepoStarted Show a new active repository
+func (p *ProgressRenderer) RepoStarted(path string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.active[path] = &repoProgress{phase: "Starting", started: time.Now()}
+}
+
+// Update Update the phase and percent of an active repository
+func (p *ProgressRenderer) Update(path string, progress GitProgress) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if repo, ok := p.active[path]; ok {
+		repo.phase = progress.Phase
+		repo.percent = progress.Percent
+	}
+}
+
+// RepoFinished Remove an active repository
+func (p *ProgressRenderer) RepoFinished(path string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.active, path)
+	p.finished++
+}
+
+// Suspend Remove the progress view while running fn, so it can print to stdout
+func (p *ProgressRenderer) Suspend(fn func()) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.clear()
+	fn()
+	p.redraw()
+}
+
+// clear Erase the lines previously drawn
+func (p *ProgressRenderer) clear() {
+	if p.drawn == 0 {
+		return
+	}
+	fmt.Fprintf(p.out, "\033[%dA\033[J", p.drawn)
+	p.drawn = 0
+}
+
+// redraw Draw one line per active repository followed by the overall progress
+func (p *ProgressRenderer) redraw() {
+	p.clear()
+	if p.total == 0 && len(p.active) == 0 {
+		return
+	}
+
+	paths := make([]string, 0, len(p.active))
+	for path := range p.active {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	width := terminalWidth() - 1
+	var view bytes.Buffer
+	for _, path := range paths {
+		repo := p.active[path]
+		line := fmt.Sprintf("  %s %s", path, repo.phase)
+		if repo.percent > 0 {
+			line += fmt.Sprintf(" %3d%%", repo.percent)
+		}
+		line += fmt.Sprintf(" (%s)", time.Since(repo.started).Round(time.Second))
+		view.WriteString(truncate(line, width) + "\n")
+	}
+	summary := fmt.Sprintf("%s [%d/%d]", p.operation, p.finished, p.total)
+	if eta, ok := p.eta(); ok {
+		summary += fmt.Sprintf(" ETA %s", eta)
+	}
+	view.WriteString(PurpleColor + truncate(summary, width) + ResetColor + "\n")
+
+	p.out.Write(view.Bytes())
+	p.drawn = len(paths) + 1
+}
+
+// eta Estimate the remaining time based on the average duration of the finished repositories
+func (p *ProgressRenderer) eta() (time.Duration, bool) {
+	if p.finished == 0 || p.finished >= p.total {
+		return 0, false
+	}
+	perRepo := time.Since(p.started) / time.Duration(p.finished)
+	return (perRepo * time.Duration(p.total-p.finished)).Round(time.Second), true
+}
+
+// truncate Shorten a line to the given width
+func truncate(line string, width int) string {
+	if width <= 0 || len(line) <= width {
+		return line
+	}
+	return line[:width]
+}
@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -16,16 +18,29 @@ type RepositoryJob struct {
 }
 
 type Repository struct {
-	Type    string   `yaml:"type"`
-	URL     string   `yaml:"url"`
-	Version string   `yaml:"version,omitempty"`
-	Exclude []string `yaml:"exclude,omitempty"`
+	Type    string   `yaml:"type" json:"type"`
+	URL     string   `yaml:"url" json:"url"`
+	Version string   `yaml:"version,omitempty" json:"version,omitempty"`
+	Exclude []string `yaml:"exclude,omitempty" json:"exclude,omitempty"`
+	// Ref Version given in the .repos file before it was locked to a commit
+	Ref string `yaml:"ref,omitempty" json:"ref,omitempty"`
+	// Source .repos file, relative to the workspace, listing a nested repository of a lockfile
+	Source string `yaml:"source,omitempty" json:"source,omitempty"`
+	// SHA256 Expected checksum of the archive of tar and zip repositories
+	SHA256 string `yaml:"sha256,omitempty" json:"sha256,omitempty"`
+	// StripComponents Number of leading path components removed when extracting tar and zip repositories
+	StripComponents int `yaml:"strip-components,omitempty" json:"strip-components,omitempty"`
+	// Tags Groups the repository belongs to, used to select repositories
+	Tags []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+	// Remotes URL of the git remotes besides origin, keyed by remote name. Origin is given by URL
+	Remotes map[string]string `yaml:"remotes,omitempty" json:"remotes,omitempty"`
 }
 type RepositoryRosinstall struct {
 	LocalName string   `yaml:"local-name"`
 	URL       string   `yaml:"uri"`
 	Version   string   `yaml:"version,omitempty"`
 	Exclude   []string `yaml:"exclude,omitempty"`
+	Tags      []string `yaml:"tags,omitempty"`
 }
 
 type Config struct {
@@ -53,6 +68,7 @@ func (c *Config) UnmarshalYAML(unmarshal func(any) error) error {
 					URL:     repo.URL,
 					Version: repo.Version,
 					Exclude: repo.Exclude,
+					Tags:    repo.Tags,
 				}
 			}
 		}
@@ -62,6 +78,9 @@ func (c *Config) UnmarshalYAML(unmarshal func(any) error) error {
 	return fmt.Errorf("failed to unmarshal as either .repos or .rosinstall format")
 }
 
+// LockFileSuffix Suffix appended to a .repos file to get its lockfile
+const LockFileSuffix = ".lock"
+
 // IsReposFileValid Check if given filePath exists and if has .repos suffix
 func IsReposFileValid(filePath string) error {
 
@@ -69,7 +88,8 @@ func IsReposFileValid(filePath string) error {
 		return errors.New("error: File does not exist")
 	}
 
-	if !strings.HasSuffix(filePath, ".repos") && !strings.HasSuffix(filePath, ".rosinstall") {
+	reposPath := strings.TrimSuffix(filePath, LockFileSuffix)
+	if !strings.HasSuffix(reposPath, ".repos") && !strings.HasSuffix(reposPath, ".rosinstall") {
 		return errors.New("error: File given does not have a valid .repos or .rosinstall extension")
 	}
 	return nil
@@ -107,37 +127,92 @@ func ParseReposFile(filePath string) (*Config, error) {
 
 }
 
-// FindReposFiles Search .repos files in a given path
+// FindReposFiles Search .repos files in a given path, or only in the cloned paths when any is given
 func FindReposFiles(rootPath string, clonedPaths []string) ([]string, error) {
-	var foundReposFiles []string
-	var err error
+	return FindReposFilesIgnoring(rootPath, clonedPaths, nil)
+}
+
+// FindReposFilesIgnoring Search .repos files like FindReposFiles, leaving out the paths ignored by
+// the given patterns in gitignore syntax, relative to rootPath, and by .rvignore files
+func FindReposFilesIgnoring(rootPath string, clonedPaths []string, ignore []string) ([]string, error) {
+	global, err := NewIgnoreMatcher(rootPath, ignore)
+	if err != nil {
+		return nil, err
+	}
 	if len(clonedPaths) == 0 {
-		err = filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if !info.IsDir() && (filepath.Ext(path) == ".repos") {
+		return findReposFiles(rootPath, IgnoreStack{global})
+	}
+	var foundReposFiles []string
+	for _, clonedPath := range clonedPaths {
+		ignoreStack, ignored, err := ignoreStackTo(rootPath, clonedPath, IgnoreStack{global})
+		if err != nil {
+			return foundReposFiles, err
+		}
+		if ignored {
+			continue
+		}
+		reposFiles, err := findReposFiles(clonedPath, ignoreStack)
+		foundReposFiles = append(foundReposFiles, reposFiles...)
+		if err != nil {
+			return foundReposFiles, err
+		}
+	}
+	return foundReposFiles, nil
+}
+
+// findReposFiles Search .repos files in a directory tree that are not ignored
+func findReposFiles(rootPath string, ignoreStack IgnoreStack) ([]string, error) {
+	var foundReposFiles []string
+	ignoreStacks := make(map[string]IgnoreStack)
+	err := filepath.WalkDir(rootPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		parentStack, ok := ignoreStacks[filepath.Dir(path)]
+		if !ok {
+			parentStack = ignoreStack
+		}
+		if !entry.IsDir() {
+			if filepath.Ext(path) == ".repos" && !parentStack.IsIgnored(path, false) {
 				foundReposFiles = append(foundReposFiles, path)
 			}
 			return nil
-		})
-	} else {
-		for _, clonedPath := range clonedPaths {
-			err = filepath.Walk(clonedPath, func(path string, info os.FileInfo, err error) error {
-				if err != nil {
-					return err
-				}
-				if !info.IsDir() && (filepath.Ext(path) == ".repos") {
-					foundReposFiles = append(foundReposFiles, path)
-				}
-				return nil
-			})
 		}
-	}
-
+		if path != rootPath && ((skippedDirectories[entry.Name()] && !IsRepository(path)) || parentStack.IsIgnored(path, true)) {
+			return filepath.SkipDir
+		}
+		matcher, err := ReadIgnoreFile(path)
+		if err != nil {
+			return err
+		}
+		ignoreStacks[path] = parentStack.Push(matcher)
+		return nil
+	})
 	return foundReposFiles, err
 }
 
+// ignoreStackTo Load the .rvignore files from a root down to the parent of a directory.
+// Also reports whether the directory is ignored
+func ignoreStackTo(rootPath string, dir string, ignoreStack IgnoreStack) (IgnoreStack, bool, error) {
+	relPath, err := filepath.Rel(rootPath, dir)
+	if err != nil || relPath == "." || !filepath.IsLocal(relPath) {
+		return ignoreStack, false, nil
+	}
+	current := rootPath
+	for _, component := range strings.Split(relPath, string(filepath.Separator)) {
+		matcher, err := ReadIgnoreFile(current)
+		if err != nil {
+			return nil, false, err
+		}
+		ignoreStack = ignoreStack.Push(matcher)
+		current = filepath.Join(current, component)
+		if ignoreStack.IsIgnored(current, true) {
+			return ignoreStack, true, nil
+		}
+	}
+	return ignoreStack, false, nil
+}
+
 // FindDirectory Search for a targetDir given a rootPath
 func FindDirectory(rootPath string, targetDir string) (string, error) {
 	if len(rootPath) == 0 {
@@ -170,6 +245,110 @@ func FindDirectory(rootPath string, targetDir string) (string, error) {
 	return dirPath, nil
 }
 
+// ReadRepositoryInfo Create a Repository object containing the given repository info
+func ReadRepositoryInfo(ctx context.Context, repoPath string, useCommit bool) (Repository, error) {
+	vcs, err := DetectVCS(repoPath)
+	if err != nil {
+		return Repository{}, err
+	}
+	repository, err := vcs.Info(ctx, repoPath, useCommit)
+	if err != nil {
+		return repository, err
+	}
+	repository.Tags, err = RepositoryTags(ctx, repoPath)
+	return repository, err
+}
+
+// RepositoryTagsFile File of the metadata directory of Mercurial and Subversion repositories
+// recording the tags of an imported repository
+const RepositoryTagsFile = "rv-tags"
+
+// RepositoryTags Get the tags recorded for a given repository. Git repositories keep them in
+// their configuration, Mercurial and Subversion ones in a file of their metadata directory
+func RepositoryTags(ctx context.Context, repoPath string) ([]string, error) {
+	if IsGitRepository(repoPath) {
+		return GitTags(ctx, repoPath)
+	}
+	tagsPath, ok := repositoryTagsPath(repoPath)
+	if !ok {
+		return nil, nil
+	}
+	data, err := os.ReadFile(tagsPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get tags of %s. Error: %w", repoPath, err)
+	}
+	return strings.Fields(string(data)), nil
+}
+
+// SetRepositoryTags Record the tags of a given repository. Empty tags remove any recorded ones
+func SetRepositoryTags(ctx context.Context, repoPath string, tags []string) error {
+	if IsGitRepository(repoPath) {
+		return GitSetTags(ctx, repoPath, tags)
+	}
+	tagsPath, ok := repositoryTagsPath(repoPath)
+	if !ok {
+		return fmt.Errorf("failed to set tags of %s. Error: unsupported repository", repoPath)
+	}
+	var err error
+	if len(tags) == 0 {
+		if err = os.Remove(tagsPath); os.IsNotExist(err) {
+			err = nil
+		}
+	} else {
+		err = os.WriteFile(tagsPath, []byte(strings.Join(tags, "\n")+"\n"), 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set tags of %s. Error: %w", repoPath, err)
+	}
+	return nil
+}
+
+// repositoryTagsPath Get the path of the tags file of a Mercurial or Subversion repository
+func repositoryTagsPath(repoPath string) (string, bool) {
+	switch {
+	case IsHgRepository(repoPath):
+		return filepath.Join(repoPath, ".hg", RepositoryTagsFile), true
+	case IsSvnRepository(repoPath):
+		return filepath.Join(repoPath, ".svn", RepositoryTagsFile), true
+	}
+	return "", false
+}
+
+// readGitRepositoryInfo Create a Repository object containing the given git repository info
+func readGitRepositoryInfo(ctx context.Context, repoPath string, useCommit bool) (Repository, error) {
+	var repository Repository
+	if !IsGitRepository(repoPath) {
+		return repository, fmt.Errorf("%s is not a git repository", repoPath)
+	}
+	url, err := GitRemoteURL(ctx, repoPath)
+	if err != nil {
+		return repository, err
+	}
+	var version string
+	if useCommit {
+		version, err = GitCommitSha(ctx, repoPath)
+	} else {
+		version, err = GitBranch(ctx, repoPath)
+	}
+	if err != nil {
+		return repository, err
+	}
+	remotes, err := GitRemotes(ctx, repoPath)
+	if err != nil {
+		return repository, err
+	}
+	delete(remotes, "origin")
+	if len(remotes) > 0 {
+		repository.Remotes = remotes
+	}
+	repository.Type = "git"
+	repository.URL = url
+	repository.Version = version
+	return repository, nil
+}
+
 // ParseRepositoryInfo Create a Repository object containing the given repository info
 func ParseRepositoryInfo(repoPath string, useCommit bool) Repository {
 	var repository Repository
@@ -186,26 +365,33 @@ func ParseRepositoryInfo(repoPath string, useCommit bool) Repository {
 	return repository
 }
 
-func GetRepoPath(repoName string) string {
+// ResolveRepoPath Get the path of a repository given either its path or its directory name
+func ResolveRepoPath(repoName string) (string, error) {
 	repoNameInfo, err := os.Stat(repoName)
 
 	if err == nil {
 		if !repoNameInfo.IsDir() {
-			PrintErrorMsg(fmt.Sprintf("%s is not a directory\n", repoName))
-			os.Exit(1)
+			return "", fmt.Errorf("%s is not a directory", repoName)
 		}
-		return repoName
+		return repoName, nil
 	}
 
 	if !os.IsNotExist(err) {
-		PrintErrorMsg(fmt.Sprintf("Error checking repository: %s\n", repoName))
-		os.Exit(1)
+		return "", fmt.Errorf("error checking repository: %s", repoName)
 	}
 
 	foundRepoPath, findErr := FindDirectory(".", repoName)
 	if findErr != nil || foundRepoPath == "" {
-		PrintErrorMsg(fmt.Sprintf("Failed to find directory named %s. Error: %v\n", repoName, findErr))
+		return "", fmt.Errorf("failed to find directory named %s. Error: %v", repoName, findErr)
+	}
+	return foundRepoPath, nil
+}
+
+func GetRepoPath(repoName string) string {
+	repoPath, err := ResolveRepoPath(repoName)
+	if err != nil {
+		PrintErrorMsg(err.Error())
 		os.Exit(1)
 	}
-	return foundRepoPath
+	return repoPath
 }
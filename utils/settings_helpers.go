@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jesseduffield/yaml"
+)
+
+// Settings Defaults of the command line flags read from the settings file
+type Settings struct {
+	// CacheDir Directory keeping a bare mirror of each imported repository
+	CacheDir string `yaml:"cache-dir"`
+}
+
+// SettingsPath Get the path of the settings file. RV_CONFIG overrides the default location
+func SettingsPath() (string, error) {
+	if path := os.Getenv("RV_CONFIG"); path != "" {
+		return path, nil
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "ripvcs", "config.yaml"), nil
+}
+
+// LoadSettings Read the settings file. A missing file results in empty settings
+func LoadSettings() (Settings, error) {
+	var settings Settings
+	path, err := SettingsPath()
+	if err != nil {
+		return settings, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return settings, nil
+	} else if err != nil {
+		return settings, fmt.Errorf("failed to read settings file %s. Error: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return settings, fmt.Errorf("invalid settings file %s. Error: %w", path, err)
+	}
+	return settings, nil
+}
@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IsSvnRepository checks if a directory is a Subversion working copy
+func IsSvnRepository(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".svn"))
+	return err == nil
+}
+
+// RunSvnCmdContext Helper method to execute a svn command that is killed once the context is done
+func RunSvnCmdContext(ctx context.Context, path string, svnCmd string, enablePrompt bool, args ...string) (string, error) {
+	var cmdArgs []string
+	if !enablePrompt {
+		cmdArgs = append(cmdArgs, "--non-interactive")
+	}
+	cmdArgs = append(cmdArgs, svnCmd)
+	cmdArgs = append(cmdArgs, args...)
+	cmd := exec.CommandContext(ctx, "svn", cmdArgs...)
+	// Keep the output stable regardless of the user locale
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	cmd.Dir = path
+	cmd.WaitDelay = gitWaitDelay
+
+	output, err := cmd.CombinedOutput()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return "", ctxErr
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return string(output), nil
+}
+
+var svnRevisionRegex = regexp.MustCompile(`^r?(\d+)$`)
+
+// SvnCheckoutTarget Get the URL and revision to check out for a version, which is either a
+// revision (e.g. 1234 or r1234), a path relative to the URL (e.g. branches/stable) or a path
+// at a revision (e.g. branches/stable@1234)
+func SvnCheckoutTarget(url string, version string) (string, string) {
+	url = strings.TrimRight(url, "/")
+	if matches := svnRevisionRegex.FindStringSubmatch(version); matches != nil {
+		return url, matches[1]
+	}
+	var revision string
+	if at := strings.LastIndex(version, "@"); at >= 0 {
+		if matches := svnRevisionRegex.FindStringSubmatch(version[at+1:]); matches != nil {
+			version, revision = version[:at], matches[1]
+		}
+	}
+	if path := strings.Trim(version, "/"); path != "" {
+		url += "/" + path
+	}
+	return url, revision
+}
+
+// ResolveSvnVersion Get the version pinning the revision a Subversion version currently points
+// to. Revisions are kept, while paths and the empty version get the last revision that changed them
+func ResolveSvnVersion(ctx context.Context, url string, version string, enablePrompt bool) (string, error) {
+	targetURL, revision := SvnCheckoutTarget(url, version)
+	if revision != "" {
+		return version, nil
+	}
+	output, err := RunSvnCmdContext(ctx, ".", "info", enablePrompt, "--show-item", "last-changed-revision", targetURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to contact Subversion repository '%s' with version '%s'. Error: %w", url, version, err)
+	}
+	revision = strings.TrimSpace(output)
+	if version == "" {
+		return revision, nil
+	}
+	return strings.Trim(version, "/") + "@" + revision, nil
+}
+
+// svnVCS Subversion repositories
+type svnVCS struct{}
+
+func (svnVCS) Type() string { return "svn" }
+
+func (svnVCS) IsRepository(dir string) bool { return IsSvnRepository(dir) }
+
+// Status The full status starts with a single line with the revision of the working copy
+func (vcs svnVCS) Status(ctx context.Context, path string, plain bool) (string, error) {
+	output, err := RunSvnCmdContext(ctx, path, "status", false)
+	if err != nil {
+		return "", fmt.Errorf("failed to check Subversion status of %s. Error: %w", path, err)
+	}
+	if plain {
+		return output, nil
+	}
+	repo, err := vcs.Info(ctx, path, true)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("At revision %s of %s\n", repo.Version, repo.URL) + output, nil
+}
+
+func (svnVCS) IsCleanStatus(output string, plain bool) bool {
+	if plain {
+		return strings.TrimSpace(output) == ""
+	}
+	return strings.Count(output, "\n") <= 1
+}
+
+func (svnVCS) Log(ctx context.Context, path string, oneline bool, numCommits int) (string, error) {
+	logArgs := []string{"--limit", fmt.Sprint(numCommits)}
+	if oneline {
+		logArgs = append(logArgs, "--quiet")
+	}
+	output, err := RunSvnCmdContext(ctx, path, "log", false, logArgs...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get Subversion log of %s. Error: %w", path, err)
+	}
+	return output, nil
+}
+
+func (svnVCS) Pull(ctx context.Context, path string) (string, error) {
+	output, err := RunSvnCmdContext(ctx, path, "update", false)
+	if err != nil {
+		return "", fmt.Errorf("failed to update Subversion working copy %s. Error: %w", path, err)
+	}
+	return output, nil
+}
+
+// Info The version of a working copy is always its revision
+func (svnVCS) Info(ctx context.Context, path string, useCommit bool) (Repository, error) {
+	var repository Repository
+	url, err := RunSvnCmdContext(ctx, path, "info", false, "--show-item", "url")
+	if err != nil {
+		return repository, fmt.Errorf("failed to get URL of %s. Error: %w", path, err)
+	}
+	revision, err := RunSvnCmdContext(ctx, path, "info", false, "--show-item", "revision")
+	if err != nil {
+		return repository, fmt.Errorf("failed to get revision of %s. Error: %w", path, err)
+	}
+	repository.Type = "svn"
+	repository.URL = strings.TrimSpace(url)
+	repository.Version = strings.TrimSpace(revision)
+	return repository, nil
+}
+
+func (svnVCS) Validate(ctx context.Context, repo Repository, enablePrompt bool) error {
+	targetURL, revision := SvnCheckoutTarget(repo.URL, repo.Version)
+	infoArgs := []string{targetURL}
+	if revision != "" {
+		infoArgs = append(infoArgs, "--revision", revision)
+	}
+	if _, err := RunSvnCmdContext(ctx, ".", "info", enablePrompt, infoArgs...); err != nil {
+		return fmt.Errorf("failed to contact Subversion repository '%s' with version '%s'. Error: %w", repo.URL, repo.Version, err)
+	}
+	return nil
+}
+
+func (vcs svnVCS) Clone(ctx context.Context, opts CloneOptions) (int, error) {
+	targetURL, revision := SvnCheckoutTarget(opts.URL, opts.Version)
+	if _, err := os.Stat(opts.Path); err == nil {
+		if !opts.OverwriteExisting {
+			return vcs.updateExisting(ctx, opts, targetURL, revision)
+		}
+		if err := os.RemoveAll(opts.Path); err != nil {
+			return FailedClone, fmt.Errorf("failed to remove existing cloning path %s. Error: %w", opts.Path, err)
+		}
+	}
+
+	checkoutArgs := []string{targetURL, opts.Path}
+	if revision != "" {
+		checkoutArgs = append(checkoutArgs, "--revision", revision)
+	}
+	if _, err := RunSvnCmdContext(ctx, ".", "checkout", opts.EnablePrompt, checkoutArgs...); err != nil {
+		os.RemoveAll(opts.Path)
+		return FailedClone, fmt.Errorf("failed to checkout %s. Error: %w", targetURL, err)
+	}
+	return SuccessfullClone, nil
+}
+
+// updateExisting Switch an existing working copy to the requested URL and revision
+func (vcs svnVCS) updateExisting(ctx context.Context, opts CloneOptions, targetURL string, revision string) (int, error) {
+	current, err := vcs.Info(ctx, opts.Path, true)
+	if err != nil {
+		return FailedClone, err
+	}
+	if current.URL == targetURL && (revision == "" || current.Version == revision) {
+		return SkippedClone, nil
+	}
+	switchArgs := []string{targetURL}
+	if revision != "" {
+		switchArgs = append(switchArgs, "--revision", revision)
+	}
+	if _, err := RunSvnCmdContext(ctx, opts.Path, "switch", opts.EnablePrompt, switchArgs...); err != nil {
+		return FailedClone, fmt.Errorf("failed to switch %s to %s. Error: %w", opts.Path, targetURL, err)
+	}
+	return SwitchedBranch, nil
+}
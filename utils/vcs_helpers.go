@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// VCS Operations on the repositories of a version control system
+type VCS interface {
+	// Type Name of the repository type used in .repos files
+	Type() string
+	// IsRepository Check if a directory is a repository of this system
+	IsRepository(dir string) bool
+	// Status Get the status of the working copy
+	Status(ctx context.Context, path string, plain bool) (string, error)
+	// IsCleanStatus Check if the output of Status reports no local changes
+	IsCleanStatus(output string, plain bool) bool
+	// Log Get the latest commits
+	Log(ctx context.Context, path string, oneline bool, numCommits int) (string, error)
+	// Pull Update the working copy to the latest remote version
+	Pull(ctx context.Context, path string) (string, error)
+	// Info Get the url and the version, or the commit if useCommit, of the working copy
+	Info(ctx context.Context, path string, useCommit bool) (Repository, error)
+	// Validate Check if the version of a remote repository is reachable
+	Validate(ctx context.Context, repo Repository, enablePrompt bool) error
+	// Clone Clone a remote repository returning SuccessfullClone, SkippedClone, SwitchedBranch or FailedClone
+	Clone(ctx context.Context, opts CloneOptions) (int, error)
+}
+
+// supportedVCS Systems checked, in order, when detecting the type of a repository
+var supportedVCS = []VCS{gitVCS{}, hgVCS{}, svnVCS{}, archiveVCS{"tar"}, archiveVCS{"zip"}}
+
+// GetVCS Get the system handling the given repository type
+func GetVCS(repoType string) (VCS, error) {
+	for _, vcs := range supportedVCS {
+		if vcs.Type() == repoType {
+			return vcs, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported repository type %s", repoType)
+}
+
+// DetectVCS Get the system managing the repository at the given path
+func DetectVCS(path string) (VCS, error) {
+	for _, vcs := range supportedVCS {
+		if vcs.IsRepository(path) {
+			return vcs, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not a supported repository", path)
+}
+
+// IsRepository Check if a directory is a repository of any supported system
+func IsRepository(dir string) bool {
+	_, err := DetectVCS(dir)
+	return err == nil
+}
+
+// ListRepositories Get a slice of all the repositories of any supported system found at the given root
+func ListRepositories(root string) ([]string, error) {
+	return WalkRepositories(root, DiscoveryOptions{}, IsRepository)
+}
+
+// gitVCS Git repositories
+type gitVCS struct{}
+
+func (gitVCS) Type() string { return "git" }
+
+func (gitVCS) IsRepository(dir string) bool { return IsGitRepository(dir) }
+
+func (gitVCS) Status(ctx context.Context, path string, plain bool) (string, error) {
+	return GitStatus(ctx, path, plain)
+}
+
+func (gitVCS) IsCleanStatus(output string, plain bool) bool {
+	if plain {
+		return strings.Count(output, "\n") <= 1
+	}
+	return strings.Contains(output, "working tree clean")
+}
+
+func (gitVCS) Log(ctx context.Context, path string, oneline bool, numCommits int) (string, error) {
+	return GitLog(ctx, path, oneline, numCommits)
+}
+
+func (gitVCS) Pull(ctx context.Context, path string) (string, error) {
+	return GitPull(ctx, path)
+}
+
+func (gitVCS) Info(ctx context.Context, path string, useCommit bool) (Repository, error) {
+	return readGitRepositoryInfo(ctx, path, useCommit)
+}
+
+func (gitVCS) Validate(ctx context.Context, repo Repository, enablePrompt bool) error {
+	valid, err := IsGitURLValidContext(ctx, repo.URL, repo.Version, enablePrompt)
+	if !valid && err == nil {
+		err = fmt.Errorf("failed to contact git repository '%s' with version '%s'", repo.URL, repo.Version)
+	}
+	return err
+}
+
+func (gitVCS) Clone(ctx context.Context, opts CloneOptions) (int, error) {
+	return CloneGitRepo(ctx, opts)
+}